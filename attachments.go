@@ -0,0 +1,82 @@
+package main
+
+import (
+    "strings"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Вложения хранятся строкой "photo:FileID" или "document:FileID"
+const (
+    AttachmentPhoto    = "photo"
+    AttachmentDocument = "document"
+    MaxCaptionLength   = 1024
+)
+
+func parseAttachment(attachment string) (string, string, bool) {
+    kind, fileID, ok := strings.Cut(attachment, ":")
+    if !ok || fileID == "" || (kind != AttachmentPhoto && kind != AttachmentDocument) {
+        return "", "", false
+    }
+    return kind, fileID, true
+}
+
+// Вложение из сообщения пользователя: самое большое фото или документ
+func messageAttachment(message *tgbotapi.Message) string {
+    if len(message.Photo) > 0 {
+        return AttachmentPhoto + ":" + message.Photo[len(message.Photo)-1].FileID
+    }
+    if message.Document != nil {
+        return AttachmentDocument + ":" + message.Document.FileID
+    }
+    return ""
+}
+
+// Сообщения для отправки текста с вложением. Текст идёт подписью, если помещается,
+// иначе вложение и текст отправляются отдельно. keyboard достаётся последнему сообщению.
+func attachmentMessages(chatID int64, text string, attachment string, keyboard interface{}) []tgbotapi.Chattable {
+    kind, fileID, ok := parseAttachment(attachment)
+    if !ok {
+        return textMessages(chatID, text, keyboard)
+    }
+    caption := text
+    if utf16Len(text) > MaxCaptionLength {
+        caption = ""
+    }
+    var fileMsg tgbotapi.Chattable
+    if kind == AttachmentPhoto {
+        photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileID(fileID))
+        photo.Caption = caption
+        if caption != "" {
+            photo.ReplyMarkup = keyboard
+        }
+        fileMsg = photo
+    } else {
+        document := tgbotapi.NewDocument(chatID, tgbotapi.FileID(fileID))
+        document.Caption = caption
+        if caption != "" {
+            document.ReplyMarkup = keyboard
+        }
+        fileMsg = document
+    }
+    if caption != "" {
+        return []tgbotapi.Chattable{fileMsg}
+    }
+    return append([]tgbotapi.Chattable{fileMsg}, textMessages(chatID, text, keyboard)...)
+}
+
+// Отправка текста с вложением (без вложения — обычное сообщение)
+func sendMsgWithAttachment(chatID int64, text string, attachment string, keyboard interface{}) {
+    for _, msg := range attachmentMessages(chatID, text, attachment, keyboard) {
+        enqueueSend(chatID, msg, SendPriorityHigh, nil)
+    }
+}
+
+// Редактирование текста или подписи сообщения (кнопки убираются)
+func editMsgOrCaption(message *tgbotapi.Message, text string) {
+    if len(message.Photo) > 0 || message.Document != nil {
+        enqueueSend(message.Chat.ID, tgbotapi.NewEditMessageCaption(message.Chat.ID, message.MessageID, text), SendPriorityHigh, nil)
+        return
+    }
+    editMsg(message.Chat.ID, message.MessageID, text)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Запись журнала действий администрации. Журнал только дополняется.
+type AuditEntry struct {
+    Time   time.Time
+    Actor  string
+    Action string
+    Target string
+    Before string
+    After  string
+}
+
+var auditMutex sync.Mutex
+
+// Поля журнала разделены "|", поэтому символ в значениях заменяется на "¦"
+func escapeAuditField(text string) string {
+    return escapeLine(strings.ReplaceAll(text, "|", "¦"))
+}
+
+func auditUser(user *User) string {
+    return fmt.Sprintf("ID %d (@%s)", user.UserID, user.Username)
+}
+
+// Добавление записи в журнал
+func recordAudit(actor string, action string, target string, before string, after string) {
+    auditMutex.Lock()
+    defer auditMutex.Unlock()
+
+    file, err := os.OpenFile(AuditFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+    if err != nil {
+        logError("Ошибка открытия audit.txt", "error", err)
+        return
+    }
+    defer file.Close()
+
+    line := fmt.Sprintf("%s|%s|%s|%s|%s|%s\n", time.Now().Format(time.RFC3339), escapeAuditField(actor), action, escapeAuditField(target), escapeAuditField(before), escapeAuditField(after))
+    if _, err := file.WriteString(line); err != nil {
+        logError("Ошибка записи в audit.txt", "error", err)
+    }
+}
+
+// Чтение журнала
+func readAudit() []AuditEntry {
+    auditMutex.Lock()
+    defer auditMutex.Unlock()
+
+    file, err := os.Open(AuditFile)
+    if err != nil {
+        return nil
+    }
+    defer file.Close()
+
+    var entries []AuditEntry
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        parts := strings.SplitN(scanner.Text(), "|", 6)
+        if len(parts) != 6 {
+            continue
+        }
+        t, _ := time.Parse(time.RFC3339, parts[0])
+        entries = append(entries, AuditEntry{
+            Time:   t,
+            Actor:  unescapeLine(parts[1]),
+            Action: parts[2],
+            Target: unescapeLine(parts[3]),
+            Before: unescapeLine(parts[4]),
+            After:  unescapeLine(parts[5]),
+        })
+    }
+    return entries
+}
+
+// Просмотр журнала: /audit [actor=@ник] [target=ID] [action=действие] [from=ГГГГ-ММ-ДД] [to=ГГГГ-ММ-ДД] [страница]
+func showAudit(chatID int64, text string, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    const itemsPerPage = 15
+    page := 1
+    var actor, target, action string
+    var from, to time.Time
+    for _, arg := range strings.Fields(text)[1:] {
+        switch {
+        case strings.HasPrefix(arg, "actor="):
+            actor = strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(arg, "actor="), "@"))
+        case strings.HasPrefix(arg, "target="):
+            target = strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(arg, "target="), "@"))
+        case strings.HasPrefix(arg, "action="):
+            action = strings.TrimPrefix(arg, "action=")
+        case strings.HasPrefix(arg, "from="):
+            t, err := time.ParseInLocation(time.DateOnly, strings.TrimPrefix(arg, "from="), time.Local)
+            if err != nil {
+                sendMsg(chatID, tr(chatID, "bad_date", "from"))
+                return
+            }
+            from = t
+        case strings.HasPrefix(arg, "to="):
+            t, err := time.ParseInLocation(time.DateOnly, strings.TrimPrefix(arg, "to="), time.Local)
+            if err != nil {
+                sendMsg(chatID, tr(chatID, "bad_date", "to"))
+                return
+            }
+            to = t.AddDate(0, 0, 1)
+        default:
+            p, err := strconv.Atoi(arg)
+            if err != nil || p < 1 {
+                sendMsg(chatID, tr(chatID, "audit.format"))
+                return
+            }
+            page = p
+        }
+    }
+
+    entries := readAudit()
+    var filtered []AuditEntry
+    for i := len(entries) - 1; i >= 0; i-- {
+        e := entries[i]
+        if actor != "" && strings.ToLower(strings.TrimPrefix(e.Actor, "@")) != actor {
+            continue
+        }
+        if target != "" && !auditTargetMatches(e.Target, target) {
+            continue
+        }
+        if action != "" && e.Action != action {
+            continue
+        }
+        if !from.IsZero() && e.Time.Before(from) {
+            continue
+        }
+        if !to.IsZero() && !e.Time.Before(to) {
+            continue
+        }
+        filtered = append(filtered, e)
+    }
+
+    if len(filtered) == 0 {
+        sendMsg(chatID, tr(chatID, "audit.empty"))
+        return
+    }
+    startIndex := (page - 1) * itemsPerPage
+    if startIndex >= len(filtered) {
+        sendMsg(chatID, tr(chatID, "no_page", page))
+        return
+    }
+    endIndex := startIndex + itemsPerPage
+    if endIndex > len(filtered) {
+        endIndex = len(filtered)
+    }
+    var sb strings.Builder
+    sb.WriteString(tr(chatID, "audit.header", page))
+    for _, e := range filtered[startIndex:endIndex] {
+        sb.WriteString(fmt.Sprintf("%s | %s | %s", e.Time.Format(time.DateTime), e.Actor, e.Action))
+        if e.Target != "" {
+            sb.WriteString(" | " + e.Target)
+        }
+        sb.WriteString("\n")
+        if e.Before != "" || e.After != "" {
+            sb.WriteString(fmt.Sprintf("   %s → %s\n", auditValue(e.Before), auditValue(e.After)))
+        }
+    }
+    sb.WriteString("\n" + tr(chatID, "shown", startIndex+1, endIndex, len(filtered)))
+    sendMsg(chatID, sb.String())
+}
+
+// Цель совпадает по ID ("ID 42"), номеру ("#42") или @нику
+func auditTargetMatches(entryTarget string, filter string) bool {
+    entryTarget = strings.NewReplacer("(", " ", ")", " ", ",", " ").Replace(strings.ToLower(entryTarget)) + " "
+    if _, err := strconv.Atoi(filter); err == nil {
+        return strings.Contains(entryTarget, "id "+filter+" ") || strings.Contains(entryTarget, "#"+filter+" ")
+    }
+    return strings.Contains(entryTarget, filter)
+}
+
+func auditValue(value string) string {
+    if value == "" {
+        return "—"
+    }
+    return value
+}
@@ -0,0 +1,587 @@
+package main
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Запись истории банов. Нулевой ExpiresAt — бессрочный бан.
+type BanRecord struct {
+    ID        int
+    UserID    int
+    ChatID    int64
+    IssuedBy  string
+    CreatedAt time.Time
+    ExpiresAt time.Time
+    LiftedBy  string
+    LiftedAt  time.Time
+    Reason    string
+}
+
+// Статусы апелляций
+const (
+    AppealPending  = "pending"
+    AppealAccepted = "accepted"
+    AppealRejected = "rejected"
+)
+
+// После отказа повторную апелляцию по тому же бану можно подать не сразу и не больше MaxAppealsPerBan раз
+const (
+    MaxAppealsPerBan = 3
+    AppealCooldown   = 24 * time.Hour
+)
+
+type Appeal struct {
+    ID         int
+    UserID     int
+    ChatID     int64
+    BanID      int
+    Status     string
+    ReviewedBy string
+    Comment    string
+    CreatedAt  time.Time
+    Text       string
+}
+
+var (
+    banHistory   []BanRecord
+    appeals      []Appeal
+    banReasons   []string
+    nextBanID    = 1
+    nextAppealID = 1
+    historyMutex sync.Mutex
+)
+
+// Загрузка истории банов и апелляций
+func loadBanHistory() {
+    historyMutex.Lock()
+    defer historyMutex.Unlock()
+
+    if file, err := os.Open(BansFile); err == nil {
+        scanner := bufio.NewScanner(file)
+        for scanner.Scan() {
+            parts := strings.SplitN(scanner.Text(), "|", 9)
+            if len(parts) == 9 {
+                id, _ := strconv.Atoi(parts[0])
+                userID, _ := strconv.Atoi(parts[1])
+                chatID, _ := strconv.ParseInt(parts[2], 10, 64)
+                createdAt, _ := time.Parse(time.RFC3339, parts[4])
+                expiresAt, _ := time.Parse(time.RFC3339, parts[5])
+                liftedAt, _ := time.Parse(time.RFC3339, parts[7])
+                banHistory = append(banHistory, BanRecord{
+                    ID:        id,
+                    UserID:    userID,
+                    ChatID:    chatID,
+                    IssuedBy:  parts[3],
+                    CreatedAt: createdAt,
+                    ExpiresAt: expiresAt,
+                    LiftedBy:  parts[6],
+                    LiftedAt:  liftedAt,
+                    Reason:    unescapeLine(parts[8]),
+                })
+                if id >= nextBanID {
+                    nextBanID = id + 1
+                }
+            }
+        }
+        file.Close()
+    }
+
+    if file, err := os.Open(AppealsFile); err == nil {
+        scanner := bufio.NewScanner(file)
+        for scanner.Scan() {
+            parts := strings.SplitN(scanner.Text(), "|", 9)
+            if len(parts) == 9 {
+                id, _ := strconv.Atoi(parts[0])
+                userID, _ := strconv.Atoi(parts[1])
+                chatID, _ := strconv.ParseInt(parts[2], 10, 64)
+                banID, _ := strconv.Atoi(parts[3])
+                createdAt, _ := time.Parse(time.RFC3339, parts[7])
+                appeals = append(appeals, Appeal{
+                    ID:         id,
+                    UserID:     userID,
+                    ChatID:     chatID,
+                    BanID:      banID,
+                    Status:     parts[4],
+                    ReviewedBy: parts[5],
+                    Comment:    unescapeLine(parts[6]),
+                    CreatedAt:  createdAt,
+                    Text:       unescapeLine(parts[8]),
+                })
+                if id >= nextAppealID {
+                    nextAppealID = id + 1
+                }
+            }
+        }
+        file.Close()
+    }
+}
+
+func formatOptionalTime(t time.Time) string {
+    if t.IsZero() {
+        return ""
+    }
+    return t.Format(time.RFC3339)
+}
+
+// Сохранение истории банов (вызывать под historyMutex)
+func saveBanHistory() {
+    file, err := os.Create(BansFile)
+    if err != nil {
+        logError("Ошибка сохранения bans.txt", "error", err)
+        return
+    }
+    defer file.Close()
+
+    for _, b := range banHistory {
+        _, err := file.WriteString(fmt.Sprintf("%d|%d|%d|%s|%s|%s|%s|%s|%s\n", b.ID, b.UserID, b.ChatID, b.IssuedBy, b.CreatedAt.Format(time.RFC3339), formatOptionalTime(b.ExpiresAt), b.LiftedBy, formatOptionalTime(b.LiftedAt), escapeLine(b.Reason)))
+        if err != nil {
+            logError("Ошибка записи бана", "ban_id", b.ID, "error", err)
+        }
+    }
+}
+
+// Сохранение апелляций (вызывать под historyMutex)
+func saveAppeals() {
+    file, err := os.Create(AppealsFile)
+    if err != nil {
+        logError("Ошибка сохранения appeals.txt", "error", err)
+        return
+    }
+    defer file.Close()
+
+    for _, a := range appeals {
+        _, err := file.WriteString(fmt.Sprintf("%d|%d|%d|%d|%s|%s|%s|%s|%s\n", a.ID, a.UserID, a.ChatID, a.BanID, a.Status, a.ReviewedBy, escapeLine(a.Comment), a.CreatedAt.Format(time.RFC3339), escapeLine(a.Text)))
+        if err != nil {
+            logError("Ошибка записи апелляции", "appeal_id", a.ID, "error", err)
+        }
+    }
+}
+
+// Загрузка шаблонов причин бана
+func loadBanReasons() {
+    if _, err := os.Stat(BanReasonsFile); os.IsNotExist(err) {
+        banReasons = []string{
+            "Нарушение правил сервера",
+            "Оскорбления игроков",
+            "Спам и флуд",
+            "Мошенничество с оплатой заказа",
+            "Реклама сторонних проектов",
+        }
+        file, err := os.Create(BanReasonsFile)
+        if err != nil {
+            logWarn("Не удалось создать ban_reasons.txt", "error", err)
+            return
+        }
+        defer file.Close()
+        for _, reason := range banReasons {
+            if _, err := file.WriteString(reason + "\n"); err != nil {
+                logWarn("Ошибка записи в ban_reasons.txt", "error", err)
+                return
+            }
+        }
+        logInfo("Создан ban_reasons.txt с шаблонами причин")
+        return
+    }
+
+    file, err := os.Open(BanReasonsFile)
+    if err != nil {
+        logWarn("Не удалось открыть ban_reasons.txt", "error", err)
+        return
+    }
+    defer file.Close()
+
+    banReasons = nil
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        if reason := strings.TrimSpace(scanner.Text()); reason != "" {
+            banReasons = append(banReasons, reason)
+        }
+    }
+}
+
+// Разбор срока бана: 30m, 12h, 7d, 2w, perm (а также старый формат 30мин).
+// Возвращает 0 для бессрочного бана; false — срок не распознан или больше
+// MaxBanDuration (огромное число переполнило бы Duration и стало бы вечным баном).
+func parseBanDuration(text string) (time.Duration, bool) {
+    text = strings.ToLower(strings.TrimSpace(text))
+    switch text {
+    case "perm", "permanent", "навсегда":
+        return 0, true
+    }
+    units := []struct {
+        suffix string
+        unit   time.Duration
+    }{
+        {"мин", time.Minute},
+        {"m", time.Minute},
+        {"h", time.Hour},
+        {"ч", time.Hour},
+        {"d", 24 * time.Hour},
+        {"д", 24 * time.Hour},
+        {"w", 7 * 24 * time.Hour},
+    }
+    for _, u := range units {
+        if !strings.HasSuffix(text, u.suffix) {
+            continue
+        }
+        n, err := strconv.Atoi(strings.TrimSuffix(text, u.suffix))
+        if err != nil || n <= 0 || int64(n) > int64(MaxBanDuration/u.unit) {
+            return 0, false
+        }
+        return time.Duration(n) * u.unit, true
+    }
+    return 0, false
+}
+
+// Подстановка шаблона причины: "#2" → вторая строка ban_reasons.txt; false — шаблона нет
+func resolveBanReason(reason string) (string, bool) {
+    reason = strings.TrimSpace(reason)
+    if !strings.HasPrefix(reason, "#") {
+        return reason, true
+    }
+    n, err := strconv.Atoi(strings.TrimPrefix(reason, "#"))
+    if err != nil || n < 1 || n > len(banReasons) {
+        return "", false
+    }
+    return banReasons[n-1], true
+}
+
+// Активен ли бан пользователя
+func isBanActive(user *User) bool {
+    return user.IsBanned && (user.BanExpires.IsZero() || time.Now().Before(user.BanExpires))
+}
+
+// Срок бана для журналов; пользователю — banUntilFor
+func banUntilText(user *User) string {
+    if user.BanExpires.IsZero() {
+        return "навсегда"
+    }
+    return "до " + user.BanExpires.Format(time.DateTime)
+}
+
+// Запись бана в историю
+func recordBan(user *User, reason string, issuedBy string) int {
+    historyMutex.Lock()
+    defer historyMutex.Unlock()
+    record := BanRecord{
+        ID:        nextBanID,
+        UserID:    user.UserID,
+        ChatID:    user.ChatID,
+        IssuedBy:  issuedBy,
+        CreatedAt: time.Now(),
+        ExpiresAt: user.BanExpires,
+        Reason:    reason,
+    }
+    nextBanID++
+    banHistory = append(banHistory, record)
+    saveBanHistory()
+    return record.ID
+}
+
+// Отметка о снятии последнего действующего бана
+func recordUnban(chatID int64, liftedBy string) {
+    historyMutex.Lock()
+    defer historyMutex.Unlock()
+    for i := len(banHistory) - 1; i >= 0; i-- {
+        if banHistory[i].ChatID == chatID && banHistory[i].LiftedAt.IsZero() {
+            banHistory[i].LiftedBy = liftedBy
+            banHistory[i].LiftedAt = time.Now()
+            saveBanHistory()
+            return
+        }
+    }
+}
+
+// Последний действующий бан пользователя (вызывать под historyMutex)
+func currentBanID(chatID int64) int {
+    for i := len(banHistory) - 1; i >= 0; i-- {
+        if banHistory[i].ChatID == chatID && banHistory[i].LiftedAt.IsZero() {
+            return banHistory[i].ID
+        }
+    }
+    return 0
+}
+
+// Шаблоны причин бана
+func showBanReasons(chatID int64, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    if len(banReasons) == 0 {
+        sendMsg(chatID, tr(chatID, "ban.reasons_none"))
+        return
+    }
+    var sb strings.Builder
+    for i, reason := range banReasons {
+        sb.WriteString(fmt.Sprintf("#%d — %s\n", i+1, reason))
+    }
+    sendMsg(chatID, tr(chatID, "ban.reasons", sb.String()))
+}
+
+// История банов пользователя
+func showBanHistory(chatID int64, text string, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    parts := strings.Fields(text)
+    if len(parts) != 2 {
+        sendMsg(chatID, tr(chatID, "ban.history_format"))
+        return
+    }
+    targetUserID, err := strconv.Atoi(parts[1])
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "bad_id"))
+        return
+    }
+    targetUser := getUserByUserID(targetUserID)
+    if targetUser == nil {
+        sendMsg(chatID, tr(chatID, "user_not_found", targetUserID))
+        return
+    }
+    lang := chatLang(chatID)
+    historyMutex.Lock()
+    var sb strings.Builder
+    count := 0
+    for _, b := range banHistory {
+        if b.ChatID != targetUser.ChatID {
+            continue
+        }
+        until := T(lang, "ban_forever")
+        if !b.ExpiresAt.IsZero() {
+            until = T(lang, "ban_until", b.ExpiresAt.Format(time.DateTime))
+        }
+        lifted := T(lang, "ban.active")
+        if !b.LiftedAt.IsZero() {
+            lifted = T(lang, "ban.lifted", b.LiftedAt.Format(time.DateTime), b.LiftedBy)
+        }
+        sb.WriteString(fmt.Sprintf("#%d | %s | %s | %s | %s\n📝 %s\n\n", b.ID, b.CreatedAt.Format(time.DateTime), b.IssuedBy, until, lifted, b.Reason))
+        count++
+    }
+    historyMutex.Unlock()
+
+    if count == 0 {
+        sendMsg(chatID, tr(chatID, "ban.history_none", targetUser.Username, targetUserID))
+        return
+    }
+    sendMsg(chatID, tr(chatID, "ban.history", targetUser.Username, targetUserID, sb.String()))
+}
+
+// Апелляция заблокированного пользователя
+func processAppealCommand(chatID int64, text string) {
+    user := getUser(chatID)
+    if user == nil || !isBanActive(user) {
+        sendMsg(chatID, tr(chatID, "appeal.not_banned"))
+        return
+    }
+    parts := strings.SplitN(text, " ", 2)
+    if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+        sendMsg(chatID, tr(chatID, "appeal.format"))
+        return
+    }
+    appealText := strings.TrimSpace(parts[1])
+
+    historyMutex.Lock()
+    banID := currentBanID(chatID)
+    filed := 0
+    var lastFiled time.Time
+    for _, a := range appeals {
+        if a.ChatID != chatID || a.BanID != banID {
+            continue
+        }
+        if a.Status != AppealRejected {
+            historyMutex.Unlock()
+            sendMsg(chatID, tr(chatID, "appeal.exists", a.ID))
+            return
+        }
+        filed++
+        if a.CreatedAt.After(lastFiled) {
+            lastFiled = a.CreatedAt
+        }
+    }
+    if filed >= MaxAppealsPerBan {
+        historyMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "appeal.limit", filed))
+        return
+    }
+    if wait := time.Until(lastFiled.Add(AppealCooldown)); filed > 0 && wait > 0 {
+        historyMutex.Unlock()
+        rounded := wait.Round(time.Minute)
+        if wait > time.Hour {
+            rounded = wait.Round(time.Hour)
+        } else if rounded < time.Minute {
+            rounded = time.Minute
+        }
+        sendMsg(chatID, tr(chatID, "appeal.cooldown", formatDurationFor(chatID, rounded)))
+        return
+    }
+    appeal := Appeal{
+        ID:        nextAppealID,
+        UserID:    user.UserID,
+        ChatID:    chatID,
+        BanID:     banID,
+        Status:    AppealPending,
+        CreatedAt: time.Now(),
+        Text:      appealText,
+    }
+    nextAppealID++
+    appeals = append(appeals, appeal)
+    saveAppeals()
+    historyMutex.Unlock()
+
+    for _, admin := range []string{AdminUser1, AdminUser2} {
+        if adminUser := getUserByUsername(admin); adminUser != nil {
+            lang := chatLang(adminUser.ChatID)
+            keyboard := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+                tgbotapi.NewInlineKeyboardButtonData(T(lang, "appeal.btn_accept"), fmt.Sprintf("appeal:accept:%d", appeal.ID)),
+                tgbotapi.NewInlineKeyboardButtonData(T(lang, "appeal.btn_reject"), fmt.Sprintf("appeal:reject:%d", appeal.ID)),
+            ))
+            card := T(lang, "appeal.card", appeal.ID, user.Username, user.UserID, user.MinecraftNick, banUntilFor(adminUser.ChatID, user), user.BanReason, appealText)
+            sendMsgWithKeyboard(adminUser.ChatID, card, keyboard)
+        }
+    }
+    sendMsg(chatID, tr(chatID, "appeal.sent", appeal.ID))
+    logInfo("Новая апелляция", "appeal_id", appeal.ID, "chat_id", chatID, "user_id", user.UserID, "username", user.Username, "text", redact(appealText))
+}
+
+// Решение по апелляции. Возвращает текст результата для персонала на языке lang.
+func reviewAppeal(appealID int, accept bool, reviewer string, comment string, lang string) (string, error) {
+    historyMutex.Lock()
+    var appeal *Appeal
+    for i := range appeals {
+        if appeals[i].ID == appealID {
+            appeal = &appeals[i]
+            break
+        }
+    }
+    if appeal == nil {
+        historyMutex.Unlock()
+        return "", errors.New(T(lang, "appeal.not_found", appealID))
+    }
+    if appeal.Status != AppealPending {
+        reviewedBy := appeal.ReviewedBy
+        historyMutex.Unlock()
+        return "", errors.New(T(lang, "appeal.reviewed", appealID, reviewedBy))
+    }
+    appeal.Status = AppealRejected
+    if accept {
+        appeal.Status = AppealAccepted
+    }
+    appeal.ReviewedBy = reviewer
+    appeal.Comment = comment
+    reviewed := *appeal
+    saveAppeals()
+    historyMutex.Unlock()
+
+    suffix := ""
+    if comment != "" {
+        suffix = tr(reviewed.ChatID, "appeal.comment", comment)
+    }
+    user := getUser(reviewed.ChatID)
+    if accept {
+        if user != nil && user.IsBanned {
+            unbanUser(user, "@"+reviewer)
+        }
+        sendMsg(reviewed.ChatID, tr(reviewed.ChatID, "appeal.accepted", suffix))
+    } else {
+        sendMsg(reviewed.ChatID, tr(reviewed.ChatID, "appeal.rejected", suffix))
+    }
+    logInfo("Апелляция рассмотрена", "appeal_id", appealID, "admin", reviewer, "user_id", reviewed.UserID, "status", reviewed.Status)
+    recordAudit("@"+reviewer, "appeal", fmt.Sprintf("#%d, ID %d", appealID, reviewed.UserID), AppealPending, reviewed.Status)
+
+    verdict := T(lang, "appeal.verdict_accept")
+    if !accept {
+        verdict = T(lang, "appeal.verdict_reject")
+    }
+    return T(lang, "appeal.result", reviewed.ID, reviewed.UserID, reviewed.Text, verdict, reviewer), nil
+}
+
+// Кнопки апелляции
+func handleAppealCallback(query *tgbotapi.CallbackQuery, args []string) {
+    lang := chatLang(callbackChatID(query))
+    if !isAdmin(query.From.UserName) {
+        answerCallback(query.ID, T(lang, "no_rights"))
+        return
+    }
+    if len(args) != 2 {
+        answerCallback(query.ID, T(lang, "bad_button"))
+        return
+    }
+    appealID, err := strconv.Atoi(args[1])
+    if err != nil {
+        answerCallback(query.ID, T(lang, "bad_button"))
+        return
+    }
+    result, err := reviewAppeal(appealID, args[0] == "accept", query.From.UserName, "", lang)
+    if err != nil {
+        answerCallback(query.ID, "❌ "+err.Error())
+        return
+    }
+    answerCallback(query.ID, T(lang, "done"))
+    if query.Message != nil {
+        editMsg(query.Message.Chat.ID, query.Message.MessageID, result)
+    }
+}
+
+// Решение по апелляции командой
+func processAppealDecisionCommand(chatID int64, text string, username string, accept bool) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    parts := strings.SplitN(text, " ", 3)
+    if len(parts) < 2 {
+        sendMsg(chatID, tr(chatID, "appeal.decision_format", parts[0]))
+        return
+    }
+    appealID, err := strconv.Atoi(strings.TrimPrefix(parts[1], "#"))
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "bad_id"))
+        return
+    }
+    comment := ""
+    if len(parts) == 3 {
+        comment = strings.TrimSpace(parts[2])
+    }
+    result, err := reviewAppeal(appealID, accept, username, comment, chatLang(chatID))
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "error", err))
+        return
+    }
+    sendMsg(chatID, result)
+}
+
+// Список апелляций на рассмотрении
+func listAppeals(chatID int64, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    historyMutex.Lock()
+    var sb strings.Builder
+    count := 0
+    for _, a := range appeals {
+        if a.Status != AppealPending {
+            continue
+        }
+        sb.WriteString(fmt.Sprintf("#%d | ID: %d | %s\n%s\n\n", a.ID, a.UserID, a.CreatedAt.Format(time.DateTime), a.Text))
+        count++
+    }
+    historyMutex.Unlock()
+
+    if count == 0 {
+        sendMsg(chatID, tr(chatID, "appeal.none"))
+        return
+    }
+    sendMsg(chatID, tr(chatID, "appeal.list", count, sb.String()))
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+    "testing"
+    "time"
+)
+
+func TestParseBanDuration(t *testing.T) {
+    tests := []struct {
+        text     string
+        duration time.Duration
+        ok       bool
+    }{
+        {"30m", 30 * time.Minute, true},
+        {"12h", 12 * time.Hour, true},
+        {"7d", 7 * 24 * time.Hour, true},
+        {"2w", 14 * 24 * time.Hour, true},
+        {"30мин", 30 * time.Minute, true},
+        {"perm", 0, true},
+        {"3650d", 3650 * 24 * time.Hour, true},
+        {"3651d", 0, false},
+        {"999999999d", 0, false},
+        {"9223372036854775807m", 0, false},
+        {"0h", 0, false},
+        {"abc", 0, false},
+    }
+    for _, test := range tests {
+        duration, ok := parseBanDuration(test.text)
+        if duration != test.duration || ok != test.ok {
+            t.Errorf("parseBanDuration(%q) = %v, %v, ожидалось %v, %v", test.text, duration, ok, test.duration, test.ok)
+        }
+    }
+}
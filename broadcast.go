@@ -0,0 +1,377 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Категории рассылок, на которые можно подписаться, и ключи их названий в каталоге
+var broadcastCategories = map[string]string{
+    "news":   "sub.cat_news",
+    "events": "sub.cat_events",
+    "trade":  "sub.cat_trade",
+}
+
+// Фоновая рассылка
+type Broadcast struct {
+    ID            int
+    AdminChatID   int64
+    Admin         string
+    Audience      string
+    Text          string
+    PhotoID       string
+    Total         int
+    Delivered     int
+    Failed        int
+    Blocked       int
+    Skipped       int // не отправлены из-за отмены
+    Cancelled     bool
+    Finished      bool
+    ProgressMsgID int
+    StartedAt     time.Time
+
+    stop      atomic.Bool // отмена для очереди отправки
+    updatedAt time.Time   // последнее обновление сообщения с прогрессом
+}
+
+var (
+    broadcasts      = make(map[int]*Broadcast)
+    broadcastMutex  sync.Mutex
+    nextBroadcastID = 1
+)
+
+// Получатели по селектору: all, users, admins, active:N (дней), sub:категория.
+// Название аудитории и ошибки — на языке lang админа.
+func resolveAudience(spec string, lang string) ([]int64, string, error) {
+    var match func(user User) bool
+    var title string
+    switch {
+    case spec == "" || spec == "all":
+        title = T(lang, "broadcast.audience_all")
+        match = func(user User) bool { return true }
+    case spec == "admins":
+        title = T(lang, "broadcast.audience_admins")
+        match = func(user User) bool { return isAdmin(user.Username) }
+    case spec == "users":
+        title = T(lang, "broadcast.audience_users")
+        match = func(user User) bool { return !isAdmin(user.Username) }
+    case strings.HasPrefix(spec, "active:"):
+        days, err := strconv.Atoi(strings.TrimPrefix(spec, "active:"))
+        if err != nil || days < 1 {
+            return nil, "", errors.New(T(lang, "broadcast.active_format"))
+        }
+        since := time.Now().AddDate(0, 0, -days)
+        title = T(lang, "broadcast.audience_active", days)
+        match = func(user User) bool { return user.LastActive.After(since) }
+    case strings.HasPrefix(spec, "sub:"):
+        category := strings.TrimPrefix(spec, "sub:")
+        nameKey, ok := broadcastCategories[category]
+        if !ok {
+            return nil, "", errors.New(T(lang, "broadcast.unknown_category", category))
+        }
+        title = T(lang, "broadcast.audience_sub", T(lang, nameKey))
+        match = func(user User) bool { return hasSubscription(&user, category) }
+    default:
+        return nil, "", errors.New(T(lang, "broadcast.unknown_audience", spec))
+    }
+
+    userMutex.Lock()
+    defer userMutex.Unlock()
+    var recipients []int64
+    for _, user := range users {
+        if !user.Inactive && match(user) {
+            recipients = append(recipients, user.ChatID)
+        }
+    }
+    return recipients, title, nil
+}
+
+// Отделение селектора аудитории "to=..." от текста объявления
+func splitAudience(text string) (string, string) {
+    text = strings.TrimSpace(text)
+    if !strings.HasPrefix(text, "to=") {
+        return "all", text
+    }
+    parts := strings.SplitN(text, " ", 2)
+    rest := ""
+    if len(parts) == 2 {
+        rest = strings.TrimSpace(parts[1])
+    }
+    return strings.TrimPrefix(parts[0], "to="), rest
+}
+
+// Запуск рассылки в фоне
+func startBroadcast(adminChatID int64, admin string, audience string, text string, photoID string) {
+    recipients, title, err := resolveAudience(audience, chatLang(adminChatID))
+    if err != nil {
+        sendMsg(adminChatID, tr(adminChatID, "error", err))
+        return
+    }
+    if len(recipients) == 0 {
+        sendMsg(adminChatID, tr(adminChatID, "broadcast.no_recipients"))
+        return
+    }
+
+    broadcastMutex.Lock()
+    b := &Broadcast{
+        ID:          nextBroadcastID,
+        AdminChatID: adminChatID,
+        Admin:       admin,
+        Audience:    title,
+        Text:        text,
+        PhotoID:     photoID,
+        Total:       len(recipients),
+        StartedAt:   time.Now(),
+        updatedAt:   time.Now(),
+    }
+    broadcasts[b.ID] = b
+    nextBroadcastID++
+    broadcastMutex.Unlock()
+
+    recordAudit("@"+admin, "broadcast", fmt.Sprintf("#%d (%s)", b.ID, title), "", text)
+    logInfo("Рассылка запущена", "broadcast_id", b.ID, "chat_id", adminChatID, "admin", admin, "audience", audience, "recipients", len(recipients))
+    runBroadcast(b, recipients)
+}
+
+// Весь список получателей ставится в очередь с низким приоритетом, результаты
+// собираются в recordBroadcastResult
+func runBroadcast(b *Broadcast, recipients []int64) {
+    enqueueSend(b.AdminChatID, tgbotapi.NewMessage(b.AdminChatID, broadcastProgressText(b, chatLang(b.AdminChatID))), SendPriorityHigh, func(sent tgbotapi.Message, err error) {
+        broadcastMutex.Lock()
+        b.ProgressMsgID = sent.MessageID
+        broadcastMutex.Unlock()
+    })
+    for _, chatID := range recipients {
+        enqueueCancellableSend(chatID, broadcastMessage(b, chatID), SendPriorityLow, &b.stop, func(_ tgbotapi.Message, err error) {
+            recordBroadcastResult(b, chatID, err)
+        })
+    }
+}
+
+// Учёт доставки одному получателю, обновление прогресса и итоговый отчёт
+func recordBroadcastResult(b *Broadcast, chatID int64, err error) {
+    broadcastMutex.Lock()
+    switch {
+    case err == nil:
+        b.Delivered++
+    case errors.Is(err, errSendCancelled):
+        b.Skipped++
+    case isBlockedError(err):
+        b.Blocked++
+    default:
+        b.Failed++
+    }
+    b.Finished = b.Delivered+b.Failed+b.Blocked+b.Skipped >= b.Total
+    finished := b.Finished
+    update := finished || time.Since(b.updatedAt) >= 3*time.Second
+    if update {
+        b.updatedAt = time.Now()
+    }
+    msgID := b.ProgressMsgID
+    broadcastMutex.Unlock()
+
+    if err != nil && !errors.Is(err, errSendCancelled) {
+        handleSendError(chatID, err)
+    }
+    if !update {
+        return
+    }
+    report := broadcastProgressText(b, chatLang(b.AdminChatID))
+    if msgID != 0 {
+        editMsg(b.AdminChatID, msgID, report)
+    } else if finished {
+        sendMsg(b.AdminChatID, report)
+    }
+    if finished {
+        logInfo("Рассылка завершена", "broadcast_id", b.ID, "delivered", b.Delivered, "failed", b.Failed, "blocked", b.Blocked, "skipped", b.Skipped, "cancelled", b.Cancelled)
+    }
+}
+
+// Объявление для получателя, заголовок на его языке
+func broadcastMessage(b *Broadcast, chatID int64) tgbotapi.Chattable {
+    text := tr(chatID, "broadcast.announcement", b.Text)
+    if b.PhotoID != "" {
+        msg := tgbotapi.NewPhoto(chatID, tgbotapi.FileID(b.PhotoID))
+        msg.Caption = text
+        return msg
+    }
+    return tgbotapi.NewMessage(chatID, text)
+}
+
+func isBlockedError(err error) bool {
+    var apiErr *tgbotapi.Error
+    if errors.As(err, &apiErr) && apiErr.Code == 403 {
+        return true
+    }
+    return strings.Contains(err.Error(), "blocked by user")
+}
+
+// Текст прогресса/отчёта рассылки. lang считается до вызова: chatLang берёт
+// userMutex, а здесь держится broadcastMutex.
+func broadcastProgressText(b *Broadcast, lang string) string {
+    broadcastMutex.Lock()
+    defer broadcastMutex.Unlock()
+    processed := b.Delivered + b.Failed + b.Blocked + b.Skipped
+    state := T(lang, "broadcast.running", b.ID)
+    if b.Finished {
+        state = T(lang, "broadcast.finished")
+        if b.Cancelled {
+            state = T(lang, "broadcast.cancelled")
+        }
+    }
+    return T(lang, "broadcast.progress", b.ID, b.Audience, state, processed, b.Total, b.Delivered, b.Failed, b.Blocked, b.Skipped, time.Since(b.StartedAt).Round(time.Second))
+}
+
+// Отмена рассылки
+func processBroadcastCancelCommand(chatID int64, text string, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    parts := strings.Fields(text)
+    if len(parts) != 2 {
+        sendMsg(chatID, tr(chatID, "broadcast.cancel_format"))
+        return
+    }
+    broadcastID, err := strconv.Atoi(strings.TrimPrefix(parts[1], "#"))
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "bad_id"))
+        return
+    }
+    broadcastMutex.Lock()
+    b, ok := broadcasts[broadcastID]
+    if !ok || b.Finished {
+        broadcastMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "broadcast.not_found", broadcastID))
+        return
+    }
+    b.Cancelled = true
+    b.stop.Store(true)
+    broadcastMutex.Unlock()
+    recordAudit("@"+username, "broadcast_cancel", fmt.Sprintf("#%d", broadcastID), "", "")
+    sendMsg(chatID, tr(chatID, "broadcast.stopping", broadcastID))
+}
+
+// Список рассылок
+func listBroadcasts(chatID int64, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    broadcastMutex.Lock()
+    var list []*Broadcast
+    for _, b := range broadcasts {
+        list = append(list, b)
+    }
+    broadcastMutex.Unlock()
+    if len(list) == 0 {
+        sendMsg(chatID, tr(chatID, "broadcast.none"))
+        return
+    }
+    sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+    if len(list) > 10 {
+        list = list[:10]
+    }
+    lang := chatLang(chatID)
+    var sb strings.Builder
+    sb.WriteString(T(lang, "broadcast.list_header"))
+    for _, b := range list {
+        sb.WriteString(broadcastProgressText(b, lang) + "\n\n")
+    }
+    sendMsg(chatID, sb.String())
+}
+
+// Подписки пользователя
+func hasSubscription(user *User, category string) bool {
+    for _, c := range user.Subscriptions {
+        if c == category {
+            return true
+        }
+    }
+    return false
+}
+
+func broadcastCategoryList(lang string) string {
+    keys := make([]string, 0, len(broadcastCategories))
+    for key := range broadcastCategories {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    var sb strings.Builder
+    for _, key := range keys {
+        sb.WriteString(fmt.Sprintf("• %s — %s\n", key, T(lang, broadcastCategories[key])))
+    }
+    return sb.String()
+}
+
+// Подписка и отписка: /subscribe [категория], /unsubscribe [категория]
+func processSubscribeCommand(chatID int64, text string, subscribe bool) {
+    user := getUser(chatID)
+    if user == nil {
+        sendMsg(chatID, tr(chatID, "register_first"))
+        return
+    }
+    lang := chatLang(chatID)
+    parts := strings.Fields(text)
+    if len(parts) != 2 {
+        sendMsg(chatID, T(lang, "sub.format", parts[0], broadcastCategoryList(lang)))
+        return
+    }
+    category := strings.ToLower(parts[1])
+    nameKey, ok := broadcastCategories[category]
+    if !ok {
+        sendMsg(chatID, T(lang, "sub.unknown", broadcastCategoryList(lang)))
+        return
+    }
+    name := T(lang, nameKey)
+
+    userMutex.Lock()
+    subscribed := hasSubscription(user, category)
+    switch {
+    case subscribe && !subscribed:
+        user.Subscriptions = append(user.Subscriptions, category)
+    case !subscribe && subscribed:
+        kept := user.Subscriptions[:0]
+        for _, c := range user.Subscriptions {
+            if c != category {
+                kept = append(kept, c)
+            }
+        }
+        user.Subscriptions = kept
+    }
+    saveUsers()
+    userMutex.Unlock()
+
+    if subscribe {
+        sendMsg(chatID, tr(chatID, "sub.on", name))
+    } else {
+        sendMsg(chatID, tr(chatID, "sub.off", name))
+    }
+}
+
+func showSubscriptions(chatID int64) {
+    user := getUser(chatID)
+    if user == nil {
+        sendMsg(chatID, tr(chatID, "register_first"))
+        return
+    }
+    lang := chatLang(chatID)
+    var sb strings.Builder
+    sb.WriteString(T(lang, "sub.header"))
+    if len(user.Subscriptions) == 0 {
+        sb.WriteString(T(lang, "sub.none"))
+    }
+    for _, c := range user.Subscriptions {
+        sb.WriteString(fmt.Sprintf("• %s — %s\n", c, T(lang, broadcastCategories[c])))
+    }
+    sb.WriteString(T(lang, "sub.categories") + broadcastCategoryList(lang))
+    sendMsg(chatID, sb.String())
+}
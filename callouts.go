@@ -0,0 +1,273 @@
+package main
+
+import (
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Статусы отзывов
+const (
+    CalloutStatusPending  = "pending"
+    CalloutStatusNew      = "new"
+    CalloutStatusRead     = "read"
+    CalloutStatusResolved = "resolved"
+)
+
+var calloutMutex sync.Mutex
+
+func calloutStatusText(lang string, status string) string {
+    switch status {
+    case CalloutStatusPending, CalloutStatusNew, CalloutStatusRead, CalloutStatusResolved:
+        return T(lang, "callout.status_"+status)
+    }
+    return status
+}
+
+// Поиск отзыва (вызывать под calloutMutex)
+func findCallout(id int) *Callout {
+    for i := range callouts {
+        if callouts[i].ID == id {
+            return &callouts[i]
+        }
+    }
+    return nil
+}
+
+// Список отзывов для админов
+func listCallouts(chatID int64, text string, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    const itemsPerPage = 10
+    status := "all"
+    page := 1
+    var from, to time.Time
+    for _, arg := range strings.Fields(text)[1:] {
+        switch {
+        case arg == "all" || arg == CalloutStatusNew || arg == CalloutStatusRead || arg == CalloutStatusResolved:
+            status = arg
+        case strings.HasPrefix(arg, "from="):
+            t, err := time.ParseInLocation(time.DateOnly, strings.TrimPrefix(arg, "from="), time.Local)
+            if err != nil {
+                sendMsg(chatID, tr(chatID, "bad_date", "from"))
+                return
+            }
+            from = t
+        case strings.HasPrefix(arg, "to="):
+            t, err := time.ParseInLocation(time.DateOnly, strings.TrimPrefix(arg, "to="), time.Local)
+            if err != nil {
+                sendMsg(chatID, tr(chatID, "bad_date", "to"))
+                return
+            }
+            to = t.AddDate(0, 0, 1)
+        default:
+            p, err := strconv.Atoi(arg)
+            if err != nil || p < 1 {
+                sendMsg(chatID, tr(chatID, "callout.list_format"))
+                return
+            }
+            page = p
+        }
+    }
+
+    calloutMutex.Lock()
+    var filtered []Callout
+    for i := len(callouts) - 1; i >= 0; i-- {
+        c := callouts[i]
+        if c.Status == CalloutStatusPending || (status != "all" && c.Status != status) {
+            continue
+        }
+        if !from.IsZero() && c.Timestamp.Before(from) {
+            continue
+        }
+        if !to.IsZero() && !c.Timestamp.Before(to) {
+            continue
+        }
+        filtered = append(filtered, c)
+    }
+    calloutMutex.Unlock()
+
+    if len(filtered) == 0 {
+        sendMsg(chatID, tr(chatID, "callout.none"))
+        return
+    }
+    startIndex := (page - 1) * itemsPerPage
+    if startIndex >= len(filtered) {
+        sendMsg(chatID, tr(chatID, "no_page", page))
+        return
+    }
+    endIndex := startIndex + itemsPerPage
+    if endIndex > len(filtered) {
+        endIndex = len(filtered)
+    }
+    lang := chatLang(chatID)
+    var sb strings.Builder
+    sb.WriteString(T(lang, "callout.list_header", status, page))
+    for _, c := range filtered[startIndex:endIndex] {
+        sb.WriteString(T(lang, "callout.line", c.ID, calloutStatusText(lang, c.Status), c.Timestamp.Format(time.DateTime), c.Username, c.UserID, c.Nick, c.Message))
+        if c.Reply != "" {
+            sb.WriteString(T(lang, "callout.reply_line", c.HandledBy, c.Reply))
+        }
+        sb.WriteString("\n")
+    }
+    sb.WriteString(T(lang, "shown", startIndex+1, endIndex, len(filtered)))
+    sendMsg(chatID, sb.String())
+}
+
+// Смена статуса отзыва
+func processCalloutStatusCommand(chatID int64, text string, username string, status string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    parts := strings.Fields(text)
+    if len(parts) != 2 {
+        sendMsg(chatID, tr(chatID, "callout.id_format", parts[0]))
+        return
+    }
+    calloutID, err := strconv.Atoi(strings.TrimPrefix(parts[1], "#"))
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "bad_id"))
+        return
+    }
+    calloutMutex.Lock()
+    c := findCallout(calloutID)
+    if c == nil {
+        calloutMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "callout.not_found", calloutID))
+        return
+    }
+    // Непроверенный отзыв проходит через очередь модерации, а не через смену статуса
+    if c.Status == CalloutStatusPending {
+        calloutMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "callout.pending", calloutID))
+        return
+    }
+    c.Status = status
+    c.HandledBy = username
+    saveCallouts()
+    calloutMutex.Unlock()
+
+    sendMsg(chatID, tr(chatID, "callout.status_set", calloutID, calloutStatusText(chatLang(chatID), status)))
+    logInfo("Статус отзыва изменён", "callout_id", calloutID, "chat_id", chatID, "admin", username, "command", parts[0], "status", status)
+}
+
+// Ответ автору отзыва
+func processCalloutReplyCommand(chatID int64, text string, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    parts := strings.SplitN(text, " ", 3)
+    if len(parts) != 3 {
+        sendMsg(chatID, tr(chatID, "callout.reply_format"))
+        return
+    }
+    calloutID, err := strconv.Atoi(strings.TrimPrefix(parts[1], "#"))
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "bad_id"))
+        return
+    }
+    replyText := strings.TrimSpace(parts[2])
+    if replyText == "" {
+        sendMsg(chatID, tr(chatID, "empty_message"))
+        return
+    }
+    calloutMutex.Lock()
+    c := findCallout(calloutID)
+    if c == nil {
+        calloutMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "callout.not_found", calloutID))
+        return
+    }
+    if c.Status == CalloutStatusPending {
+        calloutMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "callout.pending", calloutID))
+        return
+    }
+    c.Reply = replyText
+    c.HandledBy = username
+    if c.Status == CalloutStatusNew {
+        c.Status = CalloutStatusRead
+    }
+    callout := *c
+    saveCallouts()
+    calloutMutex.Unlock()
+
+    author := getUserByUserID(callout.UserID)
+    if author == nil {
+        sendMsg(chatID, tr(chatID, "callout.author_gone", calloutID))
+        return
+    }
+    sendMsg(author.ChatID, tr(author.ChatID, "callout.reply", callout.Message, replyText))
+    sendMsg(chatID, tr(chatID, "callout.replied", calloutID, author.Username))
+    logInfo("Ответ на отзыв", "callout_id", calloutID, "chat_id", chatID, "admin", username, "command", "/callout_reply", "text", redact(replyText))
+}
+
+// Статистика отзывов по неделям
+func showCalloutStats(chatID int64, text string, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    weeks := 8
+    parts := strings.Fields(text)
+    if len(parts) == 2 {
+        n, err := strconv.Atoi(parts[1])
+        if err != nil || n < 1 || n > 52 {
+            sendMsg(chatID, tr(chatID, "callout.stats_format"))
+            return
+        }
+        weeks = n
+    }
+
+    type weekStats struct {
+        total    int
+        resolved int
+    }
+    now := time.Now()
+    today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+    currentWeek := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+    oldest := currentWeek.AddDate(0, 0, -7*(weeks-1))
+
+    stats := make(map[time.Time]*weekStats)
+    calloutMutex.Lock()
+    for _, c := range callouts {
+        if c.Status == CalloutStatusPending || c.Timestamp.Before(oldest) {
+            continue
+        }
+        day := time.Date(c.Timestamp.Year(), c.Timestamp.Month(), c.Timestamp.Day(), 0, 0, 0, 0, now.Location())
+        week := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+        s, ok := stats[week]
+        if !ok {
+            s = &weekStats{}
+            stats[week] = s
+        }
+        s.total++
+        if c.Status == CalloutStatusResolved {
+            s.resolved++
+        }
+    }
+    calloutMutex.Unlock()
+
+    var keys []time.Time
+    for week := oldest; !week.After(currentWeek); week = week.AddDate(0, 0, 7) {
+        keys = append(keys, week)
+    }
+    sort.Slice(keys, func(i, j int) bool { return keys[i].After(keys[j]) })
+
+    var sb strings.Builder
+    sb.WriteString(tr(chatID, "callout.stats_header", weeks))
+    for _, week := range keys {
+        s := stats[week]
+        if s == nil {
+            s = &weekStats{}
+        }
+        sb.WriteString(tr(chatID, "callout.stats_line", week.Format("02.01"), week.AddDate(0, 0, 6).Format("02.01.2006"), s.total, s.resolved))
+    }
+    sendMsg(chatID, sb.String())
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+    "testing"
+    "time"
+)
+
+func TestCalloutLineRoundTrip(t *testing.T) {
+    callout := Callout{
+        ID:        7,
+        UserID:    42,
+        Username:  "steve",
+        Nick:      "Steve",
+        Message:   "лагает | спавн\nи \\n в чате",
+        Timestamp: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+        Status:    CalloutStatusResolved,
+        HandledBy: "@admin",
+        Reply:     "починили | спасибо\nпроверьте",
+    }
+    got, ok := parseCalloutLine(formatCalloutLine(callout))
+    if !ok {
+        t.Fatal("строка не разобрана")
+    }
+    if !got.Timestamp.Equal(callout.Timestamp) {
+        t.Errorf("время = %v, ожидалось %v", got.Timestamp, callout.Timestamp)
+    }
+    got.Timestamp = callout.Timestamp
+    if got != callout {
+        t.Errorf("после сохранения и загрузки %+v, ожидалось %+v", got, callout)
+    }
+}
+
+func TestCalloutLineLegacy(t *testing.T) {
+    got, ok := parseCalloutLine("42|steve|Steve|старый отзыв|2024-01-02T03:04:05Z")
+    if !ok {
+        t.Fatal("старая строка не разобрана")
+    }
+    if got.Message != "старый отзыв" || got.Status != CalloutStatusNew || got.ID != 0 {
+        t.Errorf("старая строка разобрана как %+v", got)
+    }
+}
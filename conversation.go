@@ -0,0 +1,570 @@
+package main
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Через сколько без ответа диалог отменяется, а черновик удаляется
+const ConversationTimeout = 30 * time.Minute
+
+// Пошаговые диалоги
+const (
+    FlowRegister = "register" // ник Minecraft
+    FlowVacancy  = "vacancy"  // что нужно, цена, оплата
+    FlowResponse = "response" // Params: ID вакансии
+    FlowAlert    = "alert"    // Params: аудитория, текст объявления
+    FlowProof    = "proof"    // Params: ID вакансии; результат работы от исполнителя
+)
+
+// Незавершённый диалог. Values — ответы на пройденные шаги, по одному на шаг.
+type Conversation struct {
+    ChatID    int64
+    Flow      string
+    UpdatedAt time.Time
+    EditStep  int // шаг, изменяемый из предпросмотра (с 1), 0 — обычный ввод
+    Params    []string
+    Values    []string
+}
+
+// Шаг диалога. Validate возвращает сохраняемое значение или текст ошибки.
+// Необязательный шаг можно пропустить командой /skip. Label и Prompt — ключи каталога.
+type conversationStep struct {
+    Label    string // название поля на кнопке «Изменить»
+    Prompt   string
+    Optional bool
+    Validate func(chatID int64, message *tgbotapi.Message) (string, string)
+}
+
+// Если Preview задан, после последнего шага показывается предпросмотр с кнопками
+// «Опубликовать», «Изменить» и «Отменить», а Finish вызывается только по кнопке.
+type conversationFlow struct {
+    Title   string // ключ каталога
+    Steps   []conversationStep
+    Preview func(chatID int64, conv Conversation) (string, string) // текст и вложение
+    Finish  func(chatID int64, username string, conv Conversation)
+}
+
+var (
+    conversations     = make(map[int64]*Conversation)
+    conversationMutex sync.Mutex
+    conversationFlows map[string]conversationFlow
+)
+
+func init() {
+    conversationFlows = map[string]conversationFlow{
+        FlowRegister: {
+            Title: "flow.register",
+            Steps: []conversationStep{
+                {Prompt: "step.nick", Validate: validateNickStep},
+            },
+            Finish: finishRegistration,
+        },
+        FlowVacancy: {
+            Title: "flow.vacancy",
+            Steps: []conversationStep{
+                {Label: "label.content", Prompt: "step.content", Validate: textStep("в вакансии")},
+                {Label: "label.price", Prompt: "step.price", Validate: textStep("в цене")},
+                {Label: "label.payment", Prompt: "step.payment", Validate: textStep("в оплате")},
+                {Label: "label.attachment", Prompt: "step.attachment", Optional: true, Validate: attachmentStep},
+            },
+            Preview: previewVacancy,
+            Finish:  finishVacancy,
+        },
+        FlowResponse: {
+            Title: "flow.response",
+            Steps: []conversationStep{
+                {Prompt: "step.response", Validate: nonEmptyStep},
+                {Prompt: "step.response_file", Optional: true, Validate: attachmentStep},
+            },
+            Finish: finishResponse,
+        },
+        FlowProof: {
+            Title: "flow.proof",
+            Steps: []conversationStep{
+                {Prompt: "step.proof", Validate: attachmentStep},
+            },
+            Finish: finishProof,
+        },
+        FlowAlert: {
+            Title: "flow.alert",
+            Steps: []conversationStep{
+                {Prompt: "step.photo", Validate: photoStep},
+            },
+            Finish: finishAlert,
+        },
+    }
+}
+
+// Загрузка диалогов
+func loadConversations() {
+    conversationMutex.Lock()
+    defer conversationMutex.Unlock()
+
+    file, err := os.Open(ConversationsFile)
+    if err != nil {
+        return
+    }
+    defer file.Close()
+
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        parts := strings.Split(scanner.Text(), "|")
+        if len(parts) < 5 {
+            continue
+        }
+        chatID, _ := strconv.ParseInt(parts[0], 10, 64)
+        updatedAt, _ := time.Parse(time.RFC3339, parts[2])
+        editStep, _ := strconv.Atoi(parts[3])
+        paramCount, _ := strconv.Atoi(parts[4])
+        fields := parts[5:]
+        if _, ok := conversationFlows[parts[1]]; !ok || paramCount > len(fields) {
+            continue
+        }
+        for i := range fields {
+            fields[i] = unescapeLine(fields[i])
+        }
+        conversations[chatID] = &Conversation{
+            ChatID:    chatID,
+            Flow:      parts[1],
+            UpdatedAt: updatedAt,
+            EditStep:  editStep,
+            Params:    fields[:paramCount],
+            Values:    fields[paramCount:],
+        }
+    }
+}
+
+// Сохранение диалогов (вызывать под conversationMutex)
+func saveConversations() {
+    file, err := os.Create(ConversationsFile)
+    if err != nil {
+        logError("Ошибка сохранения conversations.txt", "error", err)
+        return
+    }
+    defer file.Close()
+
+    for _, conv := range conversations {
+        line := fmt.Sprintf("%d|%s|%s|%d|%d", conv.ChatID, conv.Flow, conv.UpdatedAt.Format(time.RFC3339), conv.EditStep, len(conv.Params))
+        for _, field := range append(append([]string{}, conv.Params...), conv.Values...) {
+            line += "|" + escapeField(field)
+        }
+        if _, err := file.WriteString(line + "\n"); err != nil {
+            logError("Ошибка записи диалога", "chat_id", conv.ChatID, "error", err)
+        }
+    }
+}
+
+// Копия текущего диалога чата
+func getConversation(chatID int64) (Conversation, bool) {
+    conversationMutex.Lock()
+    defer conversationMutex.Unlock()
+    conv, ok := conversations[chatID]
+    if !ok {
+        return Conversation{}, false
+    }
+    return *conv, true
+}
+
+// Сохранение диалога и перенос его тайм-аута
+func putConversation(conv Conversation) {
+    conv.UpdatedAt = time.Now()
+    conversationMutex.Lock()
+    conversations[conv.ChatID] = &conv
+    saveConversations()
+    conversationMutex.Unlock()
+    scheduleJob(JobConversationTimeout, conv.ChatID, conv.UpdatedAt.Add(ConversationTimeout), "")
+}
+
+func endConversation(chatID int64) {
+    conversationMutex.Lock()
+    delete(conversations, chatID)
+    saveConversations()
+    conversationMutex.Unlock()
+    cancelJobs(JobConversationTimeout, chatID)
+}
+
+// Начало диалога. Уже начатый диалог заменяется новым.
+func startConversation(chatID int64, flow string, params ...string) {
+    conv := Conversation{ChatID: chatID, Flow: flow, Params: params}
+    putConversation(conv)
+    promptStep(conv)
+}
+
+// Вопрос текущего шага или предпросмотр, если все ответы получены
+func promptStep(conv Conversation) {
+    flow := conversationFlows[conv.Flow]
+    step := len(conv.Values)
+    if conv.EditStep > 0 {
+        step = conv.EditStep - 1
+    } else if step == len(flow.Steps) {
+        showPreview(conv)
+        return
+    }
+    hint := tr(conv.ChatID, "conv.hint_cancel")
+    if flow.Steps[step].Optional {
+        hint = tr(conv.ChatID, "conv.hint_skip") + hint
+    }
+    if conv.EditStep > 0 {
+        hint = tr(conv.ChatID, "conv.hint_preview") + hint
+    } else if step > 0 {
+        hint = tr(conv.ChatID, "conv.hint_back") + hint
+    }
+    sendMsg(conv.ChatID, fmt.Sprintf("%s\n\n(%s)", tr(conv.ChatID, flow.Steps[step].Prompt), hint))
+}
+
+func showPreview(conv Conversation) {
+    flow := conversationFlows[conv.Flow]
+    lang := chatLang(conv.ChatID)
+    var editRow []tgbotapi.InlineKeyboardButton
+    for i, step := range flow.Steps {
+        editRow = append(editRow, tgbotapi.NewInlineKeyboardButtonData("✏️ "+T(lang, step.Label), fmt.Sprintf("conv:edit:%d", i+1)))
+    }
+    keyboard := tgbotapi.NewInlineKeyboardMarkup(
+        tgbotapi.NewInlineKeyboardRow(
+            tgbotapi.NewInlineKeyboardButtonData(T(lang, "conv.publish"), "conv:publish"),
+            tgbotapi.NewInlineKeyboardButtonData(T(lang, "conv.discard"), "conv:discard"),
+        ),
+        editRow,
+    )
+    text, attachment := flow.Preview(conv.ChatID, conv)
+    sendMsgWithAttachment(conv.ChatID, T(lang, "conv.preview")+text, attachment, keyboard)
+}
+
+// Обработка сообщения внутри диалога: /cancel, /back, /help или ответ на шаг
+func handleConversation(chatID int64, message *tgbotapi.Message, text string, conv Conversation) {
+    flow := conversationFlows[conv.Flow]
+    switch {
+    case text == "/cancel":
+        endConversation(chatID)
+        sendMsg(chatID, tr(chatID, "conv.cancelled", tr(chatID, flow.Title)))
+        return
+    case text == "/back":
+        if conv.EditStep > 0 {
+            conv.EditStep = 0
+        } else if len(conv.Values) == 0 {
+            sendMsg(chatID, tr(chatID, "conv.first_step"))
+            return
+        } else {
+            conv.Values = conv.Values[:len(conv.Values)-1]
+        }
+        putConversation(conv)
+        promptStep(conv)
+        return
+    case text == "/help":
+        sendHelp(chatID, message.From.UserName)
+        promptStep(conv)
+        return
+    case text == "/skip":
+        step := len(conv.Values)
+        if conv.EditStep > 0 {
+            step = conv.EditStep - 1
+        }
+        if step >= len(flow.Steps) || !flow.Steps[step].Optional {
+            sendMsg(chatID, tr(chatID, "conv.no_skip"))
+            return
+        }
+        if conv.EditStep > 0 {
+            conv.Values[step] = ""
+            conv.EditStep = 0
+        } else {
+            conv.Values = append(conv.Values, "")
+        }
+        advanceConversation(message.From.UserName, conv)
+        return
+    case strings.HasPrefix(text, "/"):
+        sendMsg(chatID, tr(chatID, "conv.busy", tr(chatID, flow.Title)))
+        return
+    }
+
+    step := len(conv.Values)
+    if conv.EditStep > 0 {
+        step = conv.EditStep - 1
+    } else if step == len(flow.Steps) {
+        sendMsg(chatID, tr(chatID, "conv.check_preview"))
+        showPreview(conv)
+        return
+    }
+    value, errText := flow.Steps[step].Validate(chatID, message)
+    if errText != "" {
+        sendMsg(chatID, errText)
+        return
+    }
+    if conv.EditStep > 0 {
+        conv.Values[step] = value
+        conv.EditStep = 0
+    } else {
+        conv.Values = append(conv.Values, value)
+    }
+    advanceConversation(message.From.UserName, conv)
+}
+
+// Переход к следующему шагу, предпросмотру или завершение диалога
+func advanceConversation(username string, conv Conversation) {
+    flow := conversationFlows[conv.Flow]
+    if len(conv.Values) < len(flow.Steps) || flow.Preview != nil {
+        putConversation(conv)
+        promptStep(conv)
+        return
+    }
+    endConversation(conv.ChatID)
+    flow.Finish(conv.ChatID, username, conv)
+}
+
+// Кнопки предпросмотра: conv:publish, conv:edit:N, conv:discard
+func handleConversationCallback(query *tgbotapi.CallbackQuery, args []string) {
+    chatID := callbackChatID(query)
+    conv, ok := getConversation(chatID)
+    flow := conversationFlows[conv.Flow]
+    if !ok || flow.Preview == nil || len(conv.Values) < len(flow.Steps) || len(args) == 0 {
+        answerCallback(query.ID, tr(chatID, "conv.stale"))
+        return
+    }
+    closePreview := func(status string) {
+        if query.Message != nil {
+            editMsgOrCaption(query.Message, query.Message.Text+query.Message.Caption+"\n\n"+status)
+        }
+    }
+
+    switch args[0] {
+    case "publish":
+        answerCallback(query.ID, "")
+        closePreview(tr(chatID, "conv.published"))
+        endConversation(chatID)
+        flow.Finish(chatID, query.From.UserName, conv)
+    case "discard":
+        answerCallback(query.ID, "")
+        closePreview(tr(chatID, "conv.discarded"))
+        endConversation(chatID)
+        sendMsg(chatID, tr(chatID, "conv.cancelled", tr(chatID, flow.Title)))
+    case "edit":
+        step := 0
+        if len(args) == 2 {
+            step, _ = strconv.Atoi(args[1])
+        }
+        if step < 1 || step > len(flow.Steps) {
+            answerCallback(query.ID, tr(chatID, "bad_button"))
+            return
+        }
+        answerCallback(query.ID, "")
+        closePreview(tr(chatID, "conv.editing", tr(chatID, flow.Steps[step-1].Label)))
+        conv.EditStep = step
+        putConversation(conv)
+        promptStep(conv)
+    default:
+        answerCallback(query.ID, tr(chatID, "unknown_button"))
+    }
+}
+
+// Проверки шагов
+
+func nonEmptyStep(chatID int64, message *tgbotapi.Message) (string, string) {
+    text := strings.TrimSpace(message.Text)
+    if text == "" {
+        return "", tr(chatID, "need_text")
+    }
+    return text, ""
+}
+
+// Непустой текст без запрещённых слов; place — где найдено слово, для отчёта
+func textStep(place string) func(int64, *tgbotapi.Message) (string, string) {
+    return func(chatID int64, message *tgbotapi.Message) (string, string) {
+        text, errText := nonEmptyStep(chatID, message)
+        if errText != "" {
+            return "", errText
+        }
+        if hasForbidden, word := containsForbiddenWords(text); hasForbidden {
+            reportForbiddenWord(chatID, word, place)
+            return "", tr(chatID, "forbidden_word", word)
+        }
+        return text, ""
+    }
+}
+
+func validateNickStep(chatID int64, message *tgbotapi.Message) (string, string) {
+    nick, errText := nonEmptyStep(chatID, message)
+    if errText != "" {
+        return "", errText
+    }
+    if errText := validateNick(chatLang(chatID), nick); errText != "" {
+        if hasForbidden, word := containsForbiddenWords(nick); hasForbidden {
+            reportForbiddenWord(chatID, word, "в нике")
+        }
+        return "", errText
+    }
+    if isNickTaken(nick) {
+        return "", tr(chatID, "nick_taken")
+    }
+    return nick, ""
+}
+
+func attachmentStep(chatID int64, message *tgbotapi.Message) (string, string) {
+    attachment := messageAttachment(message)
+    if attachment == "" {
+        return "", tr(chatID, "need_attachment")
+    }
+    return attachment, ""
+}
+
+func photoStep(chatID int64, message *tgbotapi.Message) (string, string) {
+    if len(message.Photo) == 0 {
+        return "", tr(chatID, "need_photo")
+    }
+    return message.Photo[len(message.Photo)-1].FileID, ""
+}
+
+// Завершение диалогов
+
+func finishRegistration(chatID int64, username string, conv Conversation) {
+    nick := conv.Values[0]
+    if isNickTaken(nick) {
+        sendMsg(chatID, tr(chatID, "nick_taken_restart"))
+        return
+    }
+    // Пользователь, записанный старой версией без ника, только получает ник
+    if user := getUser(chatID); user != nil {
+        userMutex.Lock()
+        user.MinecraftNick = nick
+        resetVerification(user)
+        saveUsers()
+        userMutex.Unlock()
+        sendMsgWithKeyboard(chatID, tr(chatID, "registered", nick, user.UserID), mainMenuKeyboard(chatLang(chatID)))
+        return
+    }
+    newUser := User{
+        Username:      username,
+        ChatID:        chatID,
+        MinecraftNick: nick,
+        UserID:        generateUserID(),
+        LastActive:    time.Now(),
+    }
+    newUser.Language = chatLang(chatID)
+    userMutex.Lock()
+    users = append(users, newUser)
+    saveUsers()
+    userMutex.Unlock()
+    sendMsgWithKeyboard(chatID, tr(chatID, "registered", nick, newUser.UserID), mainMenuKeyboard(newUser.Language))
+}
+
+// Вакансия из ответов диалога; ID — тот, что получит вакансия при публикации сейчас
+func draftVacancy(chatID int64, conv Conversation) Vacancy {
+    vac := Vacancy{
+        ID:          nextVacancyID,
+        Content:     conv.Values[0],
+        Price:       conv.Values[1],
+        PaymentInfo: conv.Values[2],
+        Attachment:  conv.Values[3],
+        ChatID:      chatID,
+        CreatedAt:   time.Now(),
+    }
+    if user := getUser(chatID); user != nil {
+        vac.Author = user.MinecraftNick
+        vac.AuthorID = user.UserID
+    }
+    return vac
+}
+
+func previewVacancy(chatID int64, conv Conversation) (string, string) {
+    vac := draftVacancy(chatID, conv)
+    return vacancyAnnouncementText(chatLang(chatID), vac), vac.Attachment
+}
+
+func finishVacancy(chatID int64, username string, conv Conversation) {
+    user := getUser(chatID)
+    if user == nil {
+        sendMsg(chatID, tr(chatID, "register_first"))
+        return
+    }
+    if count := countOpenVacancies(chatID); count >= MaxOpenVacancies {
+        sendMsg(chatID, tr(chatID, "open_limit", count, MaxOpenVacancies))
+        return
+    }
+    vac := draftVacancy(chatID, conv)
+    suspicious, word := containsSuspiciousWords(vac.Content + "\n" + vac.Price + "\n" + vac.PaymentInfo)
+    vac.OnModeration = suspicious
+    vacancies = append(vacancies, vac)
+    saveVacancies()
+    nextVacancyID++
+    scheduleVacancyJobs(vac)
+
+    if suspicious {
+        enqueueModeration(ModerationVacancy, vac.ID, chatID, user.MinecraftNick, fmt.Sprintf("Нужно: %s\nЦена: %s\nОплата: %s", vac.Content, vac.Price, vac.PaymentInfo), vac.Attachment, word)
+        sendMsg(chatID, tr(chatID, "vac.on_moderation", vac.ID))
+        return
+    }
+    announceVacancy(vac)
+    sendMsg(chatID, tr(chatID, "vac.created"))
+}
+
+func finishResponse(chatID int64, username string, conv Conversation) {
+    vacID, err := strconv.Atoi(conv.Params[0])
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "resp.lost"))
+        return
+    }
+    submitResponse(chatID, username, vacID, conv.Values[0], conv.Values[1])
+}
+
+// Результат работы: сохраняется у вакансии и отправляется автору
+func finishProof(chatID int64, username string, conv Conversation) {
+    vacID, err := strconv.Atoi(conv.Params[0])
+    vacancyIndex := findVacancyIndex(vacID)
+    if err != nil || vacancyIndex == -1 {
+        sendMsg(chatID, tr(chatID, "vac.not_found", vacID))
+        return
+    }
+    user := getUser(chatID)
+    vac := &vacancies[vacancyIndex]
+    if user == nil || !vac.Accepted || vac.AcceptorID != user.UserID {
+        sendMsg(chatID, tr(chatID, "proof.not_acceptor", vacID))
+        return
+    }
+    vac.Proof = conv.Values[0]
+    saveVacancies()
+    sendMsg(chatID, tr(chatID, "proof.sent", vacID))
+    if getUser(vac.ChatID) != nil {
+        sendMsgWithAttachment(vac.ChatID, tr(vac.ChatID, "proof.note", vacID, user.MinecraftNick, user.UserID), vac.Proof, nil)
+    }
+    logInfo("Работа сдана", "vacancy_id", vacID, "chat_id", chatID, "user_id", user.UserID, "username", username)
+}
+
+func finishAlert(chatID int64, username string, conv Conversation) {
+    startBroadcast(chatID, username, conv.Params[0], conv.Params[1], conv.Values[0])
+}
+
+// Тайм-аут диалога: черновик удаляется, пользователь получает уведомление
+func runConversationTimeoutJob(job Job) {
+    conversationMutex.Lock()
+    conv, ok := conversations[job.Target]
+    if !ok || time.Since(conv.UpdatedAt) < ConversationTimeout {
+        conversationMutex.Unlock()
+        return
+    }
+    title := conversationFlows[conv.Flow].Title
+    delete(conversations, job.Target)
+    saveConversations()
+    conversationMutex.Unlock()
+    sendMsg(job.Target, tr(job.Target, "conv.timeout", tr(job.Target, title), formatDurationFor(job.Target, ConversationTimeout)))
+}
+
+// Тайм-ауты для диалогов, восстановленных из файла
+func ensureConversationJobs() {
+    conversationMutex.Lock()
+    var pending []Conversation
+    for _, conv := range conversations {
+        pending = append(pending, *conv)
+    }
+    conversationMutex.Unlock()
+    for _, conv := range pending {
+        if !hasJob(JobConversationTimeout, conv.ChatID) {
+            scheduleJob(JobConversationTimeout, conv.ChatID, conv.UpdatedAt.Add(ConversationTimeout), "")
+        }
+    }
+}
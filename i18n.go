@@ -0,0 +1,1274 @@
+package main
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Языки интерфейса. Русский — язык по умолчанию и запасной для отсутствующих переводов.
+const (
+    LangRU      = "ru"
+    LangEN      = "en"
+    DefaultLang = LangRU
+)
+
+var languageNames = map[string]string{
+    LangRU: "🇷🇺 Русский",
+    LangEN: "🇬🇧 English",
+}
+
+// Язык из Telegram (LanguageCode) для чатов, где пользователь ещё не выбрал язык.
+// Выбор через /lang до регистрации отмечается в chosenLanguages и сохраняется в файл.
+var (
+    chatLanguages   = make(map[int64]string)
+    chosenLanguages = make(map[int64]bool)
+    chatLangMutex   sync.Mutex
+)
+
+// Каталог сообщений. Формы множественного числа разделяются "|":
+// для русского — одна, две, пять (1 минута, 2 минуты, 5 минут), для английского — одна и много.
+var catalog = map[string]map[string]string{
+    LangRU: {
+        "start":              "👋 Добро пожаловать! Введите /help для списка команд.",
+        "unknown_command":    "❌ Неизвестная команда. Введите /help.",
+        "not_registered":     "❌ Вы не зарегистрированы.",
+        "register_first":     "❌ Сначала зарегистрируйтесь (/register).",
+        "already_registered": "❌ Вы уже зарегистрированы!",
+        "register_resume":    "ℹ️ Регистрация не завершена — сначала укажите ник Minecraft.",
+        "bad_id":             "❌ Некорректный ID.",
+        "bad_button":         "❌ Некорректная кнопка.",
+        "unknown_button":     "❌ Неизвестная кнопка.",
+        "no_rights":          "❌ У вас нет прав.",
+        "error":              "❌ Ошибка: %s.",
+        "done":               "Готово",
+        "user_not_found":     "❌ Пользователь с ID %d не найден.",
+        "no_page":            "❌ Страница %d не существует.",
+        "bad_date":           "❌ Некорректная дата. Формат: %s=ГГГГ-ММ-ДД",
+        "shown":              "📄 Показано %d-%d из %d.",
+        "empty_message":      "❌ Сообщение не может быть пустым.",
+        "forbidden_word":     "❌ Сообщение содержит запрещённое слово: %s.",
+        "banned":             "🚫 Вы заблокированы %s. Причина: %s.\nОбжаловать: /appeal [сообщение]",
+        "banned_short":       "🚫 Вы заблокированы.",
+        "ban_forever":        "навсегда",
+        "ban_until":          "до %s",
+        "ban_expired":        "🔓 Срок вашей блокировки истек.",
+        "unbanned":           "✅ Вы разблокированы.",
+        "too_fast":           "⏳ Слишком часто.",
+        "muted":              "🔇 Слишком много сообщений. Бот не будет отвечать вам %s.",
+        "welcome_back":       "👋 С возвращением! Ваши вакансии снова опубликованы: %d.",
+        "no_nick":            "❌ У вас не установлен ник Minecraft.",
+        "open_limit":         "❌ У вас уже %d открытых вакансий (максимум %d). Удалите ненужные: /my_vacancies",
+
+        "minutes": "%d минуту|%d минуты|%d минут",
+        "hours":   "%d час|%d часа|%d часов",
+        "days":    "%d день|%d дня|%d дней",
+
+        "menu.list":    "📋 Вакансии",
+        "menu.create":  "🛠 Создать вакансию",
+        "menu.mine":    "📂 Мои вакансии",
+        "menu.profile": "👤 Профиль",
+        "menu.help":    "❓ Помощь",
+
+        "lang.current": "🌐 Язык: %s\nВыберите язык или отправьте /lang ru|en.",
+        "lang.set":     "✅ Язык интерфейса: %s.",
+        "lang.unknown": "❌ Доступные языки: ru, en.",
+
+        "flow.register":       "регистрация",
+        "flow.vacancy":        "создание вакансии",
+        "flow.response":       "отклик на вакансию",
+        "flow.alert":          "объявление с фото",
+        "flow.proof":          "сдача работы",
+        "step.nick":           "Введите свой ник Minecraft (3–16 символов: латинские буквы, цифры и _):",
+        "step.content":        "1. Что вам нужно? (например: 32 стопки мха)",
+        "step.price":          "2. Сколько вы предлагаете? (например, 2 алмаза)",
+        "step.payment":        "3. Куда и как производить оплату? (например, сундук на x:100, y:64, z:200)",
+        "step.attachment":     "4. 📎 Приложите фото или документ (скриншот постройки, карта координат).",
+        "step.response":       "✉️ Напишите текст отклика.",
+        "step.response_file":  "📎 Можно приложить фото или документ (например, примеры ваших работ).",
+        "step.proof":          "🏁 Приложите фото или документ — подтверждение выполненной работы.",
+        "step.photo":          "📸 Отправьте фото.",
+        "label.content":       "Что нужно",
+        "label.price":         "Цена",
+        "label.payment":       "Оплата",
+        "label.attachment":    "Вложение",
+        "conv.hint_cancel":    "/cancel — отменить",
+        "conv.hint_skip":      "/skip — пропустить, ",
+        "conv.hint_back":      "/back — назад, ",
+        "conv.hint_preview":   "/back — к предпросмотру, ",
+        "conv.cancelled":      "❎ Отменено: %s.",
+        "conv.first_step":     "ℹ️ Это первый шаг. /cancel — отменить.",
+        "conv.no_skip":        "ℹ️ Этот шаг нельзя пропустить.",
+        "conv.busy":           "ℹ️ Сейчас идёт %s. Ответьте на вопрос или отправьте /cancel, чтобы отменить.",
+        "conv.check_preview":  "ℹ️ Проверьте предпросмотр и нажмите кнопку под ним.",
+        "conv.preview":        "👀 Предпросмотр:\n\n",
+        "conv.publish":        "✅ Опубликовать",
+        "conv.discard":        "🗑 Отменить",
+        "conv.published":      "✅ Опубликовано",
+        "conv.discarded":      "🗑 Отменено",
+        "conv.editing":        "✏️ Изменяется: %s",
+        "conv.stale":          "❌ Черновик не найден или устарел.",
+        "conv.timeout":        "⌛ Отменено: %s — не было ответа %s. Черновик удалён.",
+        "need_text":           "❌ Отправьте текстовое сообщение.",
+        "need_attachment":     "❌ Отправьте фото или документ либо /skip, чтобы пропустить.",
+        "need_photo":          "❌ Отправьте фото.",
+        "nick_taken":          "❌ Этот ник уже занят.",
+        "nick.format":         "❌ Ник должен быть длиной от 3 до 16 символов и состоять из латинских букв, цифр и _.",
+        "nick.reserved":       "❌ Этот ник зарезервирован.",
+        "nick.forbidden":      "❌ Ник содержит запрещённое слово: %s.",
+        "nick.request_format": "❌ Формат: /request_nick [новый_ник]",
+        "nick.same":           "ℹ️ Это ваш текущий ник.",
+        "nick.cooldown":       "⏳ Ник можно менять не чаще раза в %s. Следующая смена — с %s.",
+        "nick.pending":        "⏳ Ваша заявка на смену ника уже на рассмотрении.",
+        "nick.requested":      "📨 Заявка на смену ника на %s отправлена администрации.",
+        "nick.changed":        "✅ Ваш ник изменён на %s. Вакансии и отклики обновлены.",
+        "nick.rejected":       "❌ Заявка на смену ника на %s отклонена.%s",
+        "nick.request_stale":  "❌ Заявка на смену ника устарела: ваш ник уже изменился.",
+        "nick_taken_restart":  "❌ Этот ник уже занят. Начните заново: /register",
+        "registered":          "✅ Регистрация завершена! Ник: %s, ID: %d",
+
+        "vac.created":       "✅ Вакансия создана!",
+        "vac.on_moderation": "⏳ Вакансия #%d отправлена на модерацию. Мы сообщим о решении.",
+        "vac.not_found":     "❌ Вакансия #%d не найдена.",
+        "vac.taken":         "❌ Вакансия уже принята.",
+        "vac.announce":      "📢 Новая вакансия!\nОт: %s\nНужно: %s\nЦена: %s\nОплата: %s\nID: #%d",
+        "vac.card":          "📌 Вакансия #%d\nНужно: %s\nЦена: %s\nОплата: %s\n",
+        "vac.card_author":   "👤 Автор: %s — 📊 принято его вакансий: %d из %d\n",
+        "vac.card_status":   "Статус: %s\n",
+        "vac.card_created":  "🕒 Создана: %s\n",
+        "vac.card_expires":  "⌛ Удаление: %s\n",
+        "vac.card_offers":   "💬 Откликов и предложений: %d",
+        "vac.card_photo":    "\n📎 Вложение: фото",
+        "vac.card_document": "\n📎 Вложение: документ",
+        "vac.card_proof":    "\n🏁 Исполнитель сдал работу",
+        "vac.open":          "🟢 Открыта",
+        "vac.accepted_by":   "✅ Принята: %s",
+        "vac.moderation":    "⏳ На модерации",
+        "vac.hold":          "💤 Приостановлена",
+        "vac.not_set":       "Не указано",
+        "vac.summary_more":  "   Подробнее: /vacancy_%d",
+        "vac.format":        "❌ Формат: /vacancy [ID]",
+        "vac.none":          "ℹ️ Нет вакансий.",
+        "vac.no_page":       "❌ Страница %d не существует.",
+        "vac.list_header":   "📋 Вакансии (Страница %d из %d):\n",
+        "vac.prev":          "◀️ Назад",
+        "vac.next":          "Вперёд ▶️",
+        "vac.btn_accept":    "✅ Принять",
+        "vac.btn_respond":   "✉️ Откликнуться",
+        "vac.btn_author":    "👤 Автор",
+        "vac.btn_report":    "🚩 Пожаловаться",
+        "vac.respond_to":    "✉️ Отклик на вакансию #%d",
+        "vac.author_gone":   "ℹ️ Автор вакансии больше не зарегистрирован.",
+        "vac.reported":      "🚩 Жалоба отправлена администрации.",
+        "vac.report_note":   "🚩 Жалоба на вакансию от %s:\n\n%s\n\nУдалить: /dell_sell333 %d",
+        "vac.expired":       "🗑 Вакансия #%d (%s) удалена, так как не была принята в течение %s.",
+        "vac.reminder":      "⏰ Вакансию #%d (%s) пока никто не принял. Через 24 ч она будет удалена.",
+        "vac.accepted":      "✅ Заказ #%d принят! Когда закончите, сдайте работу: /done %d",
+        "vac.accepted_note": "✉️ Заказ #%d принят @%s (%s)! Связаться: /chat %d (ID: %d)",
+        "vac.count":         "%d вакансия|%d вакансии|%d вакансий",
+
+        "my.none":    "ℹ️ У вас нет вакансий.",
+        "my.header":  "📋 Ваши вакансии (%s):\n\n",
+        "my.active":  "🟢 Активна",
+        "my.line":    "#%d | %s | %s | Оплата: %s | %s\n",
+        "my.format":  "❌ Формат: /delete_vacancy [ID]",
+        "my.not_own": "❌ Вакансия не найдена или не ваша.",
+        "my.deleted": "✅ Вакансия #%d удалена.",
+
+        "resp.format":        "❌ Формат: 'Отклик: [ID_вакансии] [предложение]'",
+        "resp.need_text":     "❌ Укажите ID и текст отклика.",
+        "resp.bad_vac_id":    "❌ Некорректный ID вакансии.",
+        "resp.forbidden":     "❌ Отклик содержит запрещённое слово: %s.",
+        "resp.on_moderation": "⏳ Отклик на вакансию #%d отправлен на модерацию.",
+        "resp.accepted":      "✅ Отклик на вакансию #%d принят!",
+        "resp.author_note":   "✉️ Вакансия #%d принята @%s (%s)!\n%s\nСвязаться: /chat %d (ID: %d)",
+        "resp.lost":          "❌ Ошибка: вакансия для отклика не найдена.",
+
+        "proof.format":       "❌ Формат: /done [ID_заказа]",
+        "proof.not_acceptor": "❌ Вакансия #%d не принята вами.",
+        "proof.sent":         "🏁 Работа по заказу #%d отправлена автору.",
+        "proof.note":         "🏁 Исполнитель сдал работу по заказу #%d: %s (ID: %d)",
+
+        "profile":        "📌 Профиль:\n🆔 ID: %d\n👤 Ник: %s\n📛 @%s\n📝 Описание: %s\n⚠️ Предупреждения: %d\n%s\n📅 Регистрация: %s",
+        "profile.banned": "🚫 Забанен %s\n📝 Причина: %s",
+        "profile.active": "✅ Активен",
+        "profile.public": "👤 %s (ID: %d)\n📛 @%s\n📝 %s\n📋 Вакансий: открытых %d, принятых %d\n💬 Написать: /chat %d",
+        "bio.format":     "❌ Формат: /set_bio [описание]",
+        "bio.empty":      "❌ Описание не может быть пустым.",
+        "bio.too_long":   "❌ Описание слишком длинное (макс. 100 символов).",
+        "bio.forbidden":  "❌ Описание содержит запрещённое слово: %s.",
+        "bio.set":        "✅ Описание профиля: %s",
+
+        "chat.format":   "❌ Формат: /chat [ID_пользователя]",
+        "chat.no_user":  "❌ Пользователь с ID %d не найден.",
+        "chat.self":     "❌ Нельзя начать чат с собой.",
+        "chat.started":  "✅ Чат с @%s (ID: %d) начат! Ваш ID: %d",
+        "chat.incoming": "✅ Чат начат с @%s (ID: %d)! Ваш ID: %d",
+
+        "verify.disabled":     "ℹ️ Подтверждение ника сейчас недоступно.",
+        "verify.already":      "✅ Ваш ник %s уже подтверждён.",
+        "verify.bad_nick":     "❌ Ник %s нельзя проверить на сервере: допустимы латинские буквы, цифры и _ (3–16 символов).",
+        "verify.cooldown":     "⏳ Код уже отправлен. Новый можно запросить через минуту.",
+        "verify.offline":      "❌ Игрок %s сейчас не в сети. Зайдите на сервер и повторите /verify.",
+        "verify.server_error": "❌ Сервер Minecraft недоступен, попробуйте позже.",
+        "verify.whisper":      "Код подтверждения для Telegram-бота: %s. Никому его не сообщайте.",
+        "verify.sent":         "📨 Код отправлен вам в игровой чат. Пришлите его сюда: /verify [код]\nКод действует %s.",
+        "verify.no_code":      "❌ Сначала запросите код: /verify",
+        "verify.wrong":        "❌ Неверный код. Осталось попыток: %d.",
+        "verify.expired":      "⌛ Код больше не действует. Запросите новый: /verify",
+        "verify.done":         "✅ Ник %s подтверждён!",
+
+        "sub.format":     "❌ Формат: %s [категория]\n\nКатегории:\n%s",
+        "sub.unknown":    "❌ Неизвестная категория.\n\nКатегории:\n%s",
+        "sub.on":         "🔔 Вы подписаны на «%s».",
+        "sub.off":        "🔕 Вы отписаны от «%s».",
+        "sub.header":     "🔔 Ваши подписки:\n",
+        "sub.none":       "нет\n",
+        "sub.categories": "\nВсе категории:\n",
+        "sub.cat_news":   "Новости сервера",
+        "sub.cat_events": "Ивенты",
+        "sub.cat_trade":  "Торговля и вакансии",
+
+        "version": "🤖 CASSMP Bot v1.9\nДля Minecraft-сообщества.",
+        "help": `
+🎮 Команды бота:
+📝 /register — Зарегистрироваться
+🛠 /create — Создать вакансию
+📋 /list [страница] — Список вакансий
+🔎 /vacancy [ID] — Подробно о вакансии
+📂 /my_vacancies — Ваши вакансии
+🗑 /delete_vacancy [ID] — Удалить свою вакансию
+👤 /profile — Ваш профиль
+✏️ /set_bio [описание] — Установить описание (до 100 символов)
+💬 /chat [ID_пользователя] — Начать диалог
+🆘 /support [сообщение] — Техподдержка
+➕ /support #[ID_обращения] [сообщение] — Дополнить обращение
+🎫 /my_tickets — Ваши обращения
+🔎 /ticket [ID_обращения] — Переписка по обращению
+✔️ /close_ticket [ID_обращения] — Закрыть обращение
+📢 /callout [отзыв] — Оставить отзыв о сервере (до 250 символов)
+🔔 /subscribe [категория] — Подписаться на рассылку
+🔕 /unsubscribe [категория] — Отписаться
+📑 /subscriptions — Ваши подписки
+🌐 /lang [ru|en] — Язык интерфейса
+✔️ /verify — Подтвердить ник через сервер Minecraft
+🏷 /request_nick [новый_ник] — Попросить сменить ник (раз в 30 дней)
+ℹ️ /version — Версия бота
+❓ /help — Справка
+↩️ /back, ❎ /cancel — Шаг назад или отмена при пошаговом вводе
+⏭ /skip — Пропустить необязательный шаг (например, вложение)
+🏁 /done [ID_заказа] — Сдать выполненную работу (фото или документ)
+Для принятия: ![ID_заказа] или кнопка «✅ Принять» под вакансией
+Команды можно писать и по-русски: /помощь, /список, /создать, /профиль, /язык, /отмена
+`,
+        "help_admin": `
+👑 Админ-команды:
+📄 /list_users — Список пользователей
+🚫 /ban_user [ID] [30m|12h|7d|perm] [причина|#шаблон] — Забанить
+📋 /ban_reasons — Шаблоны причин бана
+📜 /ban_history [ID] — История банов
+⚖️ /appeals — Апелляции на рассмотрении
+✅ /appeal_accept [ID] [комментарий] — Принять апелляцию (снять бан)
+❌ /appeal_reject [ID] [комментарий] — Отклонить апелляцию
+✅ /unban_user [ID] — Разбанить
+⚠️ /warn [ID] [причина] — Выдать предупреждение
+↩️ /unwarn [ID_предупреждения] — Снять предупреждение
+📑 /strikes [ID] — Предупреждения пользователя
+❌ /del_user [ID] [причина] — Удалить пользователя
+🔄 /change_id [ID] [новый_ID] — Изменить ID
+✏️ /change_nick [ID] [новый_ник] — Изменить ник
+🗑 /dell_sell333 [ID_вакансии] — Удалить вакансию
+📢 /Оповищения [to=аудитория] [сообщение] — Текстовое объявление
+🖼 /Alerts [to=аудитория] [сообщение] — Объявление с фото
+   Аудитория: all, users, admins, active:N (дней), sub:категория
+📡 /broadcasts — Последние рассылки
+⛔ /broadcast_cancel [ID] — Остановить рассылку
+📩 /reply [ID_обращения] [сообщение] — Ответ техподдержки
+🎫 /tickets [active|open|answered|closed|mine|unassigned|all] — Обращения
+🙋 /claim [ID_обращения] — Взять обращение в работу
+📬 /callouts [new|read|resolved|all] [страница] [from=ГГГГ-ММ-ДД] [to=ГГГГ-ММ-ДД] — Отзывы
+👁 /callout_read [ID] — Отметить отзыв прочитанным
+☑️ /callout_resolve [ID] — Отметить отзыв решённым
+💬 /callout_reply [ID] [сообщение] — Ответить автору отзыва
+📊 /callout_stats [недель] — Отзывы по неделям
+🛡 /modqueue — Очередь модерации
+👍 /approve [ID] — Одобрить материал
+👎 /reject [ID] [причина] — Отклонить материал
+📌 /assign [ID_обращения] [@админ] — Назначить обращение
+🚫 /banwords — Список запрещённых слов
+🚫 /banwords [подстрока|=слово|re:шаблон|+исключение|?подозрительное] — Добавить правило фильтра
+🔍 /banwords check [текст] — Проверить текст фильтром
+✅ /delbanword [запись] — Удалить правило фильтра
+📜 /audit [actor=@ник] [target=ID] [action=действие] [from=ГГГГ-ММ-ДД] [to=ГГГГ-ММ-ДД] [страница] — Журнал действий
+🔁 /restart_bot — Перезапустить бота
+🧹 lovs — Перенести лог в архив
+💣 sell_lot_poi_good22366552998 — Удалить все вакансии
+☠️ sell_lot_poi_good2236655299865541111976hhffrtt — Удалить всех пользователей
+`,
+
+        "mod.kind_vacancy":      "Вакансия",
+        "mod.kind_response":     "Отклик",
+        "mod.kind_offer":        "Предложение",
+        "mod.kind_callout":      "Отзыв",
+        "mod.kind_nick":         "Смена ника",
+        "mod.approve":           "✅ Одобрить",
+        "mod.reject":            "❌ Отклонить",
+        "mod.attachment":        "📎 Вложение к материалу #%d",
+        "mod.card":              "🛡 Модерация #%d\n%s%s от %s",
+        "mod.card_nick":         "🛡 Модерация #%d\n%s от %s (ID: %d)",
+        "mod.card_match":        "\nСовпадение: %s",
+        "mod.not_found":         "материал #%d не найден",
+        "mod.reviewed":          "материал #%d уже рассмотрен (@%s)",
+        "mod.approved":          "✅ Одобрено",
+        "mod.rejected":          "❌ Отклонено",
+        "mod.vac_published":     "✅ Вакансия #%d прошла модерацию и опубликована.",
+        "mod.vac_gone":          "ℹ️ Вакансия #%d одобрена, но уже удалена.",
+        "mod.callout_ok":        "✅ Ваш отзыв прошёл модерацию. Спасибо!",
+        "mod.reason":            " Причина: %s",
+        "mod.vac_rejected":      "❌ Вакансия #%d не прошла модерацию.%s",
+        "mod.response_rejected": "❌ Ваш отклик на вакансию #%d не прошёл модерацию.%s",
+        "mod.offer_rejected":    "❌ Ваше предложение по вакансии #%d не прошло модерацию.%s",
+        "mod.callout_rejected":  "❌ Ваш отзыв не прошёл модерацию.%s",
+        "mod.format":            "❌ Формат: %s [ID] [комментарий]",
+        "mod.empty":             "✅ Очередь модерации пуста.",
+        "mod.pending":           "🛡 На модерации: %d",
+
+        "strike.issued":        "⚠️ Вы получили предупреждение: %s\nАктивных предупреждений: %d. Предупреждения сгорают через %s.",
+        "strike.auto_ban":      "🚫 @%s (ID: %d) автоматически забанен до %s: %s.",
+        "strike.format":        "❌ Формат: /warn [ID] [причина]",
+        "strike.self":          "❌ Нельзя выдать предупреждение себе.",
+        "strike.warned":        "✅ @%s (ID: %d) получил предупреждение. Активных: %d.",
+        "strike.unwarn_format": "❌ Формат: /unwarn [ID_предупреждения]",
+        "strike.not_found":     "❌ Предупреждение #%d не найдено.",
+        "strike.removed":       "✅ Предупреждение #%d (ID пользователя: %d) снято.",
+        "strike.list_format":   "❌ Формат: /strikes [ID]",
+        "strike.until":         "до %s",
+        "strike.expired":       "истёк",
+        "strike.none":          "ℹ️ У пользователя %d нет предупреждений.",
+        "strike.list":          "⚠️ Предупреждения ID %d (активных: %d):\n\n%s",
+
+        "audit.format": "❌ Формат: /audit [actor=@ник] [target=ID|@ник] [action=действие] [from=ГГГГ-ММ-ДД] [to=ГГГГ-ММ-ДД] [страница]",
+        "audit.empty":  "ℹ️ Записей в журнале нет.",
+        "audit.header": "📜 Журнал действий (страница %d):\n\n",
+
+        "callout.status_pending":  "⏳ На модерации",
+        "callout.status_new":      "🆕 Новый",
+        "callout.status_read":     "👁 Прочитан",
+        "callout.status_resolved": "☑️ Решён",
+        "callout.list_format":     "❌ Формат: /callouts [new|read|resolved|all] [страница] [from=ГГГГ-ММ-ДД] [to=ГГГГ-ММ-ДД]",
+        "callout.none":            "ℹ️ Отзывов нет.",
+        "callout.list_header":     "📬 Отзывы (%s, страница %d):\n\n",
+        "callout.line":            "#%d | %s | %s | @%s (ID: %d, Ник: %s)\n%s\n",
+        "callout.reply_line":      "↪️ Ответ @%s: %s\n",
+        "callout.id_format":       "❌ Формат: %s [ID_отзыва]",
+        "callout.not_found":       "❌ Отзыв #%d не найден.",
+        "callout.pending":         "⏳ Отзыв #%d ещё на модерации, сначала рассмотрите его в /modqueue.",
+        "callout.status_set":      "✅ Отзыв #%d: %s.",
+        "callout.reply_format":    "❌ Формат: /callout_reply [ID_отзыва] [сообщение]",
+        "callout.author_gone":     "⚠️ Ответ сохранён, но автор отзыва #%d больше не зарегистрирован.",
+        "callout.reply":           "📩 Ответ администрации на ваш отзыв:\n«%s»\n\n%s",
+        "callout.replied":         "✅ Ответ на отзыв #%d отправлен @%s.",
+        "callout.stats_format":    "❌ Формат: /callout_stats [недель от 1 до 52]",
+        "callout.stats_header":    "📊 Отзывы за последние %d нед.:\n\n",
+        "callout.stats_line":      "%s — %s: %d (решено: %d)\n",
+
+        "ban.format":             "❌ Формат: /ban_user [ID] [срок: 30m|12h|7d|perm] [причина|#шаблон]",
+        "ban.bad_duration":       "❌ Некорректный срок '%s', примеры: 30m, 12h, 7d, perm.",
+        "ban.no_template":        "❌ Шаблон причины %s не найден, список: /ban_reasons.",
+        "ban.need_reason":        "❌ Укажите причину.",
+        "ban.self":               "❌ Нельзя забанить себя.",
+        "ban.done":               "✅ @%s (ID: %d) забанен %s. Причина: %s",
+        "ban.reasons_none":       "ℹ️ Шаблонов причин нет. Добавьте их в ban_reasons.txt.",
+        "ban.reasons":            "📋 Шаблоны причин бана:\n\n%s\nИспользование: /ban_user [ID] [срок] #[номер]",
+        "ban.history_format":     "❌ Формат: /ban_history [ID]",
+        "ban.active":             "действует",
+        "ban.lifted":             "снят %s (%s)",
+        "ban.history_none":       "ℹ️ У @%s (ID: %d) банов не было.",
+        "ban.history":            "📜 История банов @%s (ID: %d):\n\n%s",
+        "appeal.not_banned":      "ℹ️ Апелляцию можно подать только во время бана.",
+        "appeal.format":          "❌ Формат: /appeal [почему бан стоит снять]",
+        "appeal.exists":          "ℹ️ Апелляция #%d по этому бану уже подана.",
+        "appeal.limit":           "❌ По этому бану уже отклонено %d апелляций, новые не принимаются.",
+        "appeal.cooldown":        "⏳ Новую апелляцию можно подать через %s.",
+        "appeal.btn_accept":      "✅ Снять бан",
+        "appeal.btn_reject":      "❌ Отклонить",
+        "appeal.card":            "⚖️ Апелляция #%d от @%s (ID: %d, Ник: %s)\nБан: %s\nПричина бана: %s\n\n%s",
+        "appeal.sent":            "✅ Апелляция #%d отправлена. Администрация рассмотрит её.",
+        "appeal.not_found":       "апелляция #%d не найдена",
+        "appeal.reviewed":        "апелляция #%d уже рассмотрена (@%s)",
+        "appeal.comment":         "\nКомментарий: %s",
+        "appeal.accepted":        "✅ Ваша апелляция одобрена, бан снят.%s",
+        "appeal.rejected":        "❌ Ваша апелляция отклонена.%s",
+        "appeal.verdict_accept":  "✅ Бан снят",
+        "appeal.verdict_reject":  "❌ Отклонена",
+        "appeal.result":          "⚖️ Апелляция #%d (ID: %d)\n%s\n\n%s @%s",
+        "appeal.decision_format": "❌ Формат: %s [ID_апелляции] [комментарий]",
+        "appeal.none":            "✅ Апелляций на рассмотрении нет.",
+        "appeal.list":            "⚖️ Апелляции (%d):\n\n%sРешение: /appeal_accept [ID] или /appeal_reject [ID] [комментарий]",
+
+        "ticket.status_open":     "🟡 Открыто",
+        "ticket.status_answered": "🟢 Отвечено",
+        "ticket.status_closed":   "⚫ Закрыто",
+        "ticket.format":          "❌ Формат: /support [сообщение] или /support #[ID_обращения] [сообщение]",
+        "ticket.bad_id":          "❌ Некорректный ID обращения.",
+        "ticket.not_found":       "❌ Обращение #%d не найдено.",
+        "ticket.closed_new":      "❌ Обращение #%d закрыто. Создайте новое: /support [сообщение]",
+        "ticket.closed":          "❌ Обращение #%d закрыто.",
+        "ticket.staff_followup":  "🆘 Дополнение к обращению #%d от @%s (ID: %d, Ник: %s):\n%s\n\nОтветить: /reply %d [сообщение]",
+        "ticket.staff_new":       "🆘 Обращение #%d от @%s (ID: %d, Ник: %s):\n%s\n\nОтветить: /reply %d [сообщение]\nВзять в работу: /claim %d",
+        "ticket.added":           "✅ Сообщение добавлено к обращению #%d.",
+        "ticket.created":         "✅ Обращение #%d отправлено. Дополнить: /support #%d [сообщение]",
+        "ticket.reply_format":    "❌ Формат: /reply [ID_обращения] [сообщение]",
+        "ticket.reply":           "📩 Ответ техподдержки по обращению #%d:\n%s\n\nОтветить: /support #%d [сообщение]",
+        "ticket.replied":         "✅ Ответ по обращению #%d отправлен @%s (ID: %d).",
+        "ticket.list_format":     "❌ Формат: /tickets [active|open|answered|closed|mine|unassigned|all]",
+        "ticket.unassigned":      "не назначено",
+        "ticket.none":            "ℹ️ Обращений нет.",
+        "ticket.list":            "🎫 Обращения (%s, %d):\n\n%s\nПодробнее: /ticket [ID]",
+        "ticket.show_format":     "❌ Формат: /ticket [ID_обращения]",
+        "ticket.header":          "🎫 Обращение #%d\nСтатус: %s\nОт: @%s (ID: %d, Ник: %s)\nСоздано: %s\n",
+        "ticket.assignee":        "Ответственный: %s\n",
+        "ticket.author_you":      "👤 Вы",
+        "ticket.author_support":  "🛠 Поддержка",
+        "ticket.mine_none":       "ℹ️ У вас нет обращений.",
+        "ticket.mine":            "🎫 Ваши обращения:\n\n%s\nПодробнее: /ticket [ID]",
+        "ticket.claim_format":    "❌ Формат: /claim [ID_обращения]",
+        "ticket.assign_format":   "❌ Формат: /assign [ID_обращения] [@админ]",
+        "ticket.not_admin":       "❌ @%s не является администратором.",
+        "ticket.assigned":        "✅ Обращение #%d назначено @%s.",
+        "ticket.assigned_note":   "📌 @%s назначил вам обращение #%d. Подробнее: /ticket %d",
+        "ticket.close_format":    "❌ Формат: /close_ticket [ID_обращения]",
+        "ticket.already_closed":  "ℹ️ Обращение #%d уже закрыто.",
+        "ticket.closed_done":     "✅ Обращение #%d закрыто.",
+        "ticket.closed_by_staff": "✅ Ваше обращение #%d закрыто техподдержкой.",
+        "ticket.closed_by_user":  "ℹ️ @%s закрыл обращение #%d.",
+
+        "log.rotated":           "Лог-файл перенесён в архив.",
+        "announce.format":       "❌ Формат: %s [to=all|users|admins|active:N|sub:категория] [сообщение]",
+        "callout.format":        "❌ Формат: /callout [отзыв]",
+        "callout.empty":         "❌ Отзыв не может быть пустым.",
+        "callout.too_long":      "❌ Отзыв слишком длинный (макс. %d символов).",
+        "callout.on_moderation": "⏳ Отзыв отправлен на модерацию.",
+        "callout.new":           "📢 Новый отзыв #%d от @%s (ID: %d, Ник: %s):\n%s\n\nОтветить: /callout_reply %d [сообщение]",
+        "callout.thanks":        "✅ Спасибо за отзыв!",
+
+        "banword.rejected":   "🚫 Текст будет отклонён, совпадение: %s.",
+        "banword.suspicious": "⚠️ Текст уйдёт на модерацию, совпадение: %s.",
+        "banword.clean":      "✅ Текст проходит фильтр.",
+        "banword.exists":     "❌ Слово '%s' уже в списке.",
+        "banword.missing":    "❌ Слово '%s' не найдено в списке.",
+        "banword.added":      "✅ Слово '%s' добавлено в запрещённые.",
+        "banword.del_format": "❌ Формат: /delbanword [слово]",
+        "banword.empty":      "❌ Слово не может быть пустым.",
+        "banword.deleted":    "✅ Слово '%s' удалено из запрещённых.",
+
+        "banword.kind_word":        "🔤 Целые слова",
+        "banword.kind_substring":   "🔡 Подстроки",
+        "banword.kind_regex":       "🧩 Регулярные выражения",
+        "banword.kind_allow":       "✅ Исключения",
+        "banword.kind_suspicious":  "⚠️ Подозрительные (на модерацию)",
+        "banword.list_empty":       "ℹ️ Список запрещённых слов пуст.",
+        "banword.list_header":      "🚫 Фильтр запрещённых слов (%d):\n",
+        "banword.list_hint":        "\nДобавить: /banwords [подстрока|=слово|re:шаблон|+исключение|?подозрительное]\nПроверить: /banwords check [текст]",
+        "banword.allow_suspicious": "исключение не может быть подозрительным",
+        "banword.empty_regex":      "пустое регулярное выражение",
+        "banword.bad_regex":        "некорректное регулярное выражение: %v",
+        "banword.no_letters":       "запись '%s' не содержит букв",
+
+        "offer.forbidden":         "❌ Предложение содержит запрещённое слово: %s.",
+        "offer.text":              "Предлагаю: %s, Цена: %s",
+        "offer.on_moderation":     "⏳ Предложение по вакансии #%d отправлено на модерацию.",
+        "offer.accepted":          "✅ Предложение по вакансии #%d принято.",
+        "offer.note":              "✉️ Предложение на вакансию #%d от %s (ID: %d): %s",
+        "admin.users_removed":     "✅ Все пользователи удалены.",
+        "admin.vacancies_removed": "✅ Все вакансии удалены.",
+
+        "admin.change_id_format":   "❌ Формат: /change_id [ID] [новый_ID]",
+        "admin.bad_new_id":         "❌ Некорректный новый ID.",
+        "admin.id_range":           "❌ ID должен быть от %d до %d.",
+        "admin.id_taken":           "❌ Этот ID занят.",
+        "admin.id_changed":         "✅ ID @%s изменён на %d.",
+        "admin.your_id_changed":    "✅ Ваш ID изменён на %d.",
+        "admin.change_nick_format": "❌ Формат: /change_nick [ID] [новый_ник]",
+        "admin.nick_changed":       "✅ Ник @%s изменён на %s.",
+        "admin.delete_vac_format":  "❌ Формат: /dell_sell333 [ID_вакансии]",
+        "admin.vac_deleted":        "✅ Вакансия #%d удалена.",
+
+        "admin.no_users":        "ℹ️ Нет пользователей.",
+        "admin.users_header":    "📋 Пользователи:\n\n",
+        "admin.user_banned":     " (🚫 Забанен %s, Причина: %s)",
+        "admin.no_bio":          "Не указано",
+        "admin.user_inactive":   " (💤 заблокировал бота)",
+        "admin.del_user_format": "❌ Формат: /del_user [ID] [причина]",
+        "admin.user_deleted":    "✅ @%s (ID: %d) удалён.",
+        "admin.account_deleted": "🚫 Аккаунт удалён. Причина: %s",
+        "admin.unban_format":    "❌ Формат: /unban_user [ID]",
+        "admin.not_banned":      "❌ @%s (ID: %d) не заблокирован.",
+        "admin.unbanned":        "✅ @%s (ID: %d) разблокирован.",
+        "admin.restart_notice":  "⚠️ Бот перезагрузится через 10 секунд.",
+        "admin.restarting":      "✅ Перезапуск через 10 секунд.",
+
+        "broadcast.audience_all":     "все",
+        "broadcast.audience_admins":  "администраторы",
+        "broadcast.audience_users":   "пользователи без прав админа",
+        "broadcast.audience_active":  "активные за %d дн.",
+        "broadcast.audience_sub":     "подписчики «%s»",
+        "broadcast.active_format":    "формат: active:[дней]",
+        "broadcast.unknown_category": "неизвестная категория %s",
+        "broadcast.unknown_audience": "неизвестная аудитория %s",
+        "broadcast.no_recipients":    "ℹ️ Нет получателей для выбранной аудитории.",
+        "broadcast.announcement":     "📢 Объявление:\n%s",
+        "broadcast.running":          "⏳ Идёт рассылка… Отменить: /broadcast_cancel %d",
+        "broadcast.finished":         "✅ Рассылка завершена",
+        "broadcast.cancelled":        "⛔ Рассылка отменена",
+        "broadcast.progress":         "📢 Рассылка #%d (%s)\n%s\n\nОбработано: %d из %d\n✅ Доставлено: %d\n❌ Ошибки: %d\n🚫 Заблокировали бота: %d\n⛔ Пропущено после отмены: %d\n⏱ %s",
+        "broadcast.cancel_format":    "❌ Формат: /broadcast_cancel [ID_рассылки]",
+        "broadcast.not_found":        "❌ Активная рассылка #%d не найдена.",
+        "broadcast.stopping":         "⛔ Рассылка #%d будет остановлена.",
+        "broadcast.none":             "ℹ️ Рассылок ещё не было.",
+        "broadcast.list_header":      "📢 Последние рассылки:\n\n",
+    },
+    LangEN: {
+        "start":              "👋 Welcome! Type /help to see the commands.",
+        "unknown_command":    "❌ Unknown command. Type /help.",
+        "not_registered":     "❌ You are not registered.",
+        "register_first":     "❌ Please register first (/register).",
+        "already_registered": "❌ You are already registered!",
+        "register_resume":    "ℹ️ Your registration is not finished — enter your Minecraft nick first.",
+        "bad_id":             "❌ Invalid ID.",
+        "bad_button":         "❌ Invalid button.",
+        "unknown_button":     "❌ Unknown button.",
+        "no_rights":          "❌ You do not have permission.",
+        "error":              "❌ Error: %s.",
+        "done":               "Done",
+        "user_not_found":     "❌ User with ID %d not found.",
+        "no_page":            "❌ Page %d does not exist.",
+        "bad_date":           "❌ Invalid date. Format: %s=YYYY-MM-DD",
+        "shown":              "📄 Showing %d-%d of %d.",
+        "empty_message":      "❌ The message cannot be empty.",
+        "forbidden_word":     "❌ The message contains a forbidden word: %s.",
+        "banned":             "🚫 You are banned %s. Reason: %s.\nAppeal: /appeal [message]",
+        "banned_short":       "🚫 You are banned.",
+        "ban_forever":        "permanently",
+        "ban_until":          "until %s",
+        "ban_expired":        "🔓 Your ban has expired.",
+        "unbanned":           "✅ You have been unbanned.",
+        "too_fast":           "⏳ Too many requests.",
+        "muted":              "🔇 Too many messages. The bot will ignore you for %s.",
+        "welcome_back":       "👋 Welcome back! Your vacancies are published again: %d.",
+        "no_nick":            "❌ You have no Minecraft nickname set.",
+        "open_limit":         "❌ You already have %d open vacancies (maximum %d). Delete the ones you no longer need: /my_vacancies",
+
+        "minutes": "%d minute|%d minutes",
+        "hours":   "%d hour|%d hours",
+        "days":    "%d day|%d days",
+
+        "menu.list":    "📋 Vacancies",
+        "menu.create":  "🛠 New vacancy",
+        "menu.mine":    "📂 My vacancies",
+        "menu.profile": "👤 Profile",
+        "menu.help":    "❓ Help",
+
+        "lang.current": "🌐 Language: %s\nChoose a language or send /lang ru|en.",
+        "lang.set":     "✅ Interface language: %s.",
+        "lang.unknown": "❌ Available languages: ru, en.",
+
+        "flow.register":       "registration",
+        "flow.vacancy":        "vacancy creation",
+        "flow.response":       "vacancy response",
+        "flow.alert":          "photo announcement",
+        "flow.proof":          "work hand-over",
+        "step.nick":           "Enter your Minecraft nickname (3–16 characters: Latin letters, digits and _):",
+        "step.content":        "1. What do you need? (e.g. 32 stacks of moss)",
+        "step.price":          "2. What do you offer? (e.g. 2 diamonds)",
+        "step.payment":        "3. Where and how will you pay? (e.g. chest at x:100, y:64, z:200)",
+        "step.attachment":     "4. 📎 Attach a photo or document (a screenshot of the build, a coordinates map).",
+        "step.response":       "✉️ Write your response.",
+        "step.response_file":  "📎 You may attach a photo or document (e.g. examples of your work).",
+        "step.proof":          "🏁 Attach a photo or document as proof of the completed work.",
+        "step.photo":          "📸 Send a photo.",
+        "label.content":       "What",
+        "label.price":         "Price",
+        "label.payment":       "Payment",
+        "label.attachment":    "Attachment",
+        "conv.hint_cancel":    "/cancel — cancel",
+        "conv.hint_skip":      "/skip — skip, ",
+        "conv.hint_back":      "/back — go back, ",
+        "conv.hint_preview":   "/back — back to preview, ",
+        "conv.cancelled":      "❎ Cancelled: %s.",
+        "conv.first_step":     "ℹ️ This is the first step. /cancel — cancel.",
+        "conv.no_skip":        "ℹ️ This step cannot be skipped.",
+        "conv.busy":           "ℹ️ You are in the middle of %s. Answer the question or send /cancel to stop.",
+        "conv.check_preview":  "ℹ️ Check the preview and press a button below it.",
+        "conv.preview":        "👀 Preview:\n\n",
+        "conv.publish":        "✅ Publish",
+        "conv.discard":        "🗑 Discard",
+        "conv.published":      "✅ Published",
+        "conv.discarded":      "🗑 Discarded",
+        "conv.editing":        "✏️ Editing: %s",
+        "conv.stale":          "❌ The draft was not found or is outdated.",
+        "conv.timeout":        "⌛ Cancelled: %s — no reply for %s. The draft was deleted.",
+        "need_text":           "❌ Please send a text message.",
+        "need_attachment":     "❌ Send a photo or document, or /skip to skip.",
+        "need_photo":          "❌ Send a photo.",
+        "nick_taken":          "❌ This nickname is already taken.",
+        "nick.format":         "❌ A nickname must be 3 to 16 characters long and contain only Latin letters, digits and _.",
+        "nick.reserved":       "❌ This nickname is reserved.",
+        "nick.forbidden":      "❌ The nickname contains a forbidden word: %s.",
+        "nick.request_format": "❌ Usage: /request_nick [new_nickname]",
+        "nick.same":           "ℹ️ This is already your nickname.",
+        "nick.cooldown":       "⏳ You can change your nickname once every %s. Next change is possible from %s.",
+        "nick.pending":        "⏳ Your nickname change request is already under review.",
+        "nick.requested":      "📨 Your request to change your nickname to %s has been sent to the administrators.",
+        "nick.changed":        "✅ Your nickname has been changed to %s. Your vacancies and responses have been updated.",
+        "nick.rejected":       "❌ Your request to change your nickname to %s was rejected.%s",
+        "nick.request_stale":  "❌ The nickname change request is outdated: your nickname has already changed.",
+        "nick_taken_restart":  "❌ This nickname is already taken. Start again: /register",
+        "registered":          "✅ Registration complete! Nickname: %s, ID: %d",
+
+        "vac.created":       "✅ Vacancy created!",
+        "vac.on_moderation": "⏳ Vacancy #%d has been sent for moderation. We will let you know the decision.",
+        "vac.not_found":     "❌ Vacancy #%d not found.",
+        "vac.taken":         "❌ This vacancy has already been accepted.",
+        "vac.announce":      "📢 New vacancy!\nFrom: %s\nNeeded: %s\nPrice: %s\nPayment: %s\nID: #%d",
+        "vac.card":          "📌 Vacancy #%d\nNeeded: %s\nPrice: %s\nPayment: %s\n",
+        "vac.card_author":   "👤 Author: %s — 📊 their vacancies accepted: %d of %d\n",
+        "vac.card_status":   "Status: %s\n",
+        "vac.card_created":  "🕒 Created: %s\n",
+        "vac.card_expires":  "⌛ Expires: %s\n",
+        "vac.card_offers":   "💬 Responses and offers: %d",
+        "vac.card_photo":    "\n📎 Attachment: photo",
+        "vac.card_document": "\n📎 Attachment: document",
+        "vac.card_proof":    "\n🏁 The work has been handed over",
+        "vac.open":          "🟢 Open",
+        "vac.accepted_by":   "✅ Accepted by %s",
+        "vac.moderation":    "⏳ Under moderation",
+        "vac.hold":          "💤 On hold",
+        "vac.not_set":       "Not specified",
+        "vac.summary_more":  "   Details: /vacancy_%d",
+        "vac.format":        "❌ Usage: /vacancy [ID]",
+        "vac.none":          "ℹ️ No vacancies.",
+        "vac.no_page":       "❌ Page %d does not exist.",
+        "vac.list_header":   "📋 Vacancies (page %d of %d):\n",
+        "vac.prev":          "◀️ Back",
+        "vac.next":          "Next ▶️",
+        "vac.btn_accept":    "✅ Accept",
+        "vac.btn_respond":   "✉️ Respond",
+        "vac.btn_author":    "👤 Author",
+        "vac.btn_report":    "🚩 Report",
+        "vac.respond_to":    "✉️ Response to vacancy #%d",
+        "vac.author_gone":   "ℹ️ The author of this vacancy is no longer registered.",
+        "vac.reported":      "🚩 Your report has been sent to the administrators.",
+        "vac.report_note":   "🚩 Vacancy report from %s:\n\n%s\n\nDelete: /dell_sell333 %d",
+        "vac.expired":       "🗑 Vacancy #%d (%s) was deleted because nobody accepted it within %s.",
+        "vac.reminder":      "⏰ Nobody has accepted vacancy #%d (%s) yet. It will be deleted in 24 h.",
+        "vac.accepted":      "✅ Order #%d accepted! When you finish, hand over the work: /done %d",
+        "vac.accepted_note": "✉️ Order #%d was accepted by @%s (%s)! Contact: /chat %d (ID: %d)",
+        "vac.count":         "%d vacancy|%d vacancies",
+
+        "my.none":    "ℹ️ You have no vacancies.",
+        "my.header":  "📋 Your vacancies (%s):\n\n",
+        "my.active":  "🟢 Active",
+        "my.line":    "#%d | %s | %s | Payment: %s | %s\n",
+        "my.format":  "❌ Usage: /delete_vacancy [ID]",
+        "my.not_own": "❌ Vacancy not found or not yours.",
+        "my.deleted": "✅ Vacancy #%d deleted.",
+
+        "resp.format":        "❌ Usage: 'Отклик: [vacancy_ID] [offer]'",
+        "resp.need_text":     "❌ Specify the ID and the response text.",
+        "resp.bad_vac_id":    "❌ Invalid vacancy ID.",
+        "resp.forbidden":     "❌ The response contains a forbidden word: %s.",
+        "resp.on_moderation": "⏳ Your response to vacancy #%d has been sent for moderation.",
+        "resp.accepted":      "✅ Your response to vacancy #%d has been accepted!",
+        "resp.author_note":   "✉️ Vacancy #%d was accepted by @%s (%s)!\n%s\nContact: /chat %d (ID: %d)",
+        "resp.lost":          "❌ Error: the vacancy for this response was not found.",
+
+        "proof.format":       "❌ Usage: /done [order_ID]",
+        "proof.not_acceptor": "❌ Vacancy #%d was not accepted by you.",
+        "proof.sent":         "🏁 The work for order #%d has been sent to the author.",
+        "proof.note":         "🏁 The work for order #%d has been handed over by %s (ID: %d)",
+
+        "profile":        "📌 Profile:\n🆔 ID: %d\n👤 Nickname: %s\n📛 @%s\n📝 Bio: %s\n⚠️ Warnings: %d\n%s\n📅 Registered: %s",
+        "profile.banned": "🚫 Banned %s\n📝 Reason: %s",
+        "profile.active": "✅ Active",
+        "profile.public": "👤 %s (ID: %d)\n📛 @%s\n📝 %s\n📋 Vacancies: %d open, %d accepted\n💬 Message: /chat %d",
+        "bio.format":     "❌ Usage: /set_bio [description]",
+        "bio.empty":      "❌ The description cannot be empty.",
+        "bio.too_long":   "❌ The description is too long (max. 100 characters).",
+        "bio.forbidden":  "❌ The description contains a forbidden word: %s.",
+        "bio.set":        "✅ Profile description: %s",
+
+        "chat.format":   "❌ Usage: /chat [user_ID]",
+        "chat.no_user":  "❌ User with ID %d not found.",
+        "chat.self":     "❌ You cannot start a chat with yourself.",
+        "chat.started":  "✅ Chat with @%s (ID: %d) started! Your ID: %d",
+        "chat.incoming": "✅ @%s (ID: %d) started a chat with you! Your ID: %d",
+
+        "verify.disabled":     "ℹ️ Nickname verification is currently unavailable.",
+        "verify.already":      "✅ Your nickname %s is already verified.",
+        "verify.bad_nick":     "❌ Nickname %s cannot be checked on the server: only Latin letters, digits and _ are allowed (3–16 characters).",
+        "verify.cooldown":     "⏳ A code has already been sent. You can request a new one in a minute.",
+        "verify.offline":      "❌ Player %s is not online. Join the server and send /verify again.",
+        "verify.server_error": "❌ The Minecraft server is unavailable, try again later.",
+        "verify.whisper":      "Telegram bot verification code: %s. Do not share it with anyone.",
+        "verify.sent":         "📨 The code has been sent to your in-game chat. Send it here: /verify [code]\nThe code is valid for %s.",
+        "verify.no_code":      "❌ Request a code first: /verify",
+        "verify.wrong":        "❌ Wrong code. Attempts left: %d.",
+        "verify.expired":      "⌛ The code is no longer valid. Request a new one: /verify",
+        "verify.done":         "✅ Nickname %s verified!",
+
+        "sub.format":     "❌ Usage: %s [category]\n\nCategories:\n%s",
+        "sub.unknown":    "❌ Unknown category.\n\nCategories:\n%s",
+        "sub.on":         "🔔 You are subscribed to «%s».",
+        "sub.off":        "🔕 You are unsubscribed from «%s».",
+        "sub.header":     "🔔 Your subscriptions:\n",
+        "sub.none":       "none\n",
+        "sub.categories": "\nAll categories:\n",
+        "sub.cat_news":   "Server news",
+        "sub.cat_events": "Events",
+        "sub.cat_trade":  "Trade and vacancies",
+
+        "version": "🤖 CASSMP Bot v1.9\nFor the Minecraft community.",
+        "help": `
+🎮 Bot commands:
+📝 /register — Register
+🛠 /create — Create a vacancy
+📋 /list [page] — List vacancies
+🔎 /vacancy [ID] — Vacancy details
+📂 /my_vacancies — Your vacancies
+🗑 /delete_vacancy [ID] — Delete your vacancy
+👤 /profile — Your profile
+✏️ /set_bio [description] — Set a description (up to 100 characters)
+💬 /chat [user_ID] — Start a chat
+🆘 /support [message] — Support
+➕ /support #[ticket_ID] [message] — Add to a ticket
+🎫 /my_tickets — Your tickets
+🔎 /ticket [ticket_ID] — Ticket conversation
+✔️ /close_ticket [ticket_ID] — Close a ticket
+📢 /callout [feedback] — Leave server feedback (up to 250 characters)
+🔔 /subscribe [category] — Subscribe to announcements
+🔕 /unsubscribe [category] — Unsubscribe
+📑 /subscriptions — Your subscriptions
+🌐 /lang [ru|en] — Interface language
+✔️ /verify — Verify your nickname on the Minecraft server
+🏷 /request_nick [new_nickname] — Request a nickname change (once every 30 days)
+ℹ️ /version — Bot version
+❓ /help — Help
+↩️ /back, ❎ /cancel — Go back or cancel during step-by-step input
+⏭ /skip — Skip an optional step (e.g. an attachment)
+🏁 /done [order_ID] — Hand over completed work (a photo or document)
+To accept: ![order_ID] or the «✅ Accept» button under a vacancy
+`,
+        "help_admin": `
+👑 Admin commands:
+📄 /list_users — List users
+🚫 /ban_user [ID] [30m|12h|7d|perm] [reason|#template] — Ban
+📋 /ban_reasons — Ban reason templates
+📜 /ban_history [ID] — Ban history
+⚖️ /appeals — Pending appeals
+✅ /appeal_accept [ID] [comment] — Accept an appeal (lift the ban)
+❌ /appeal_reject [ID] [comment] — Reject an appeal
+✅ /unban_user [ID] — Unban
+⚠️ /warn [ID] [reason] — Issue a warning
+↩️ /unwarn [warning_ID] — Remove a warning
+📑 /strikes [ID] — A user's warnings
+❌ /del_user [ID] [reason] — Delete a user
+🔄 /change_id [ID] [new_ID] — Change an ID
+✏️ /change_nick [ID] [new_nickname] — Change a nickname
+🗑 /dell_sell333 [vacancy_ID] — Delete a vacancy
+📢 /Оповищения [to=audience] [message] — Text announcement
+🖼 /Alerts [to=audience] [message] — Announcement with a photo
+   Audience: all, users, admins, active:N (days), sub:category
+📡 /broadcasts — Recent broadcasts
+⛔ /broadcast_cancel [ID] — Stop a broadcast
+📩 /reply [ticket_ID] [message] — Support reply
+🎫 /tickets [active|open|answered|closed|mine|unassigned|all] — Tickets
+🙋 /claim [ticket_ID] — Take a ticket
+📬 /callouts [new|read|resolved|all] [page] [from=YYYY-MM-DD] [to=YYYY-MM-DD] — Feedback
+👁 /callout_read [ID] — Mark feedback as read
+☑️ /callout_resolve [ID] — Mark feedback as resolved
+💬 /callout_reply [ID] [message] — Reply to the feedback author
+📊 /callout_stats [weeks] — Feedback by week
+🛡 /modqueue — Moderation queue
+👍 /approve [ID] — Approve an item
+👎 /reject [ID] [reason] — Reject an item
+📌 /assign [ticket_ID] [@admin] — Assign a ticket
+🚫 /banwords — Forbidden word list
+🚫 /banwords [substring|=word|re:pattern|+exception|?suspicious] — Add a filter rule
+🔍 /banwords check [text] — Test text against the filter
+✅ /delbanword [entry] — Remove a filter rule
+📜 /audit [actor=@nick] [target=ID] [action=action] [from=YYYY-MM-DD] [to=YYYY-MM-DD] [page] — Action log
+🔁 /restart_bot — Restart the bot
+🧹 lovs — Archive the log
+💣 sell_lot_poi_good22366552998 — Delete all vacancies
+☠️ sell_lot_poi_good2236655299865541111976hhffrtt — Delete all users
+`,
+
+        "mod.kind_vacancy":      "Vacancy",
+        "mod.kind_response":     "Response",
+        "mod.kind_offer":        "Offer",
+        "mod.kind_callout":      "Feedback",
+        "mod.kind_nick":         "Nickname change",
+        "mod.approve":           "✅ Approve",
+        "mod.reject":            "❌ Reject",
+        "mod.attachment":        "📎 Attachment to item #%d",
+        "mod.card":              "🛡 Moderation #%d\n%s%s from %s",
+        "mod.card_nick":         "🛡 Moderation #%d\n%s from %s (ID: %d)",
+        "mod.card_match":        "\nMatch: %s",
+        "mod.not_found":         "item #%d not found",
+        "mod.reviewed":          "item #%d has already been reviewed (@%s)",
+        "mod.approved":          "✅ Approved",
+        "mod.rejected":          "❌ Rejected",
+        "mod.vac_published":     "✅ Vacancy #%d passed moderation and has been published.",
+        "mod.vac_gone":          "ℹ️ Vacancy #%d was approved but has already been deleted.",
+        "mod.callout_ok":        "✅ Your feedback passed moderation. Thank you!",
+        "mod.reason":            " Reason: %s",
+        "mod.vac_rejected":      "❌ Vacancy #%d did not pass moderation.%s",
+        "mod.response_rejected": "❌ Your response to vacancy #%d did not pass moderation.%s",
+        "mod.offer_rejected":    "❌ Your offer for vacancy #%d did not pass moderation.%s",
+        "mod.callout_rejected":  "❌ Your feedback did not pass moderation.%s",
+        "mod.format":            "❌ Usage: %s [ID] [comment]",
+        "mod.empty":             "✅ The moderation queue is empty.",
+        "mod.pending":           "🛡 Pending moderation: %d",
+
+        "strike.issued":        "⚠️ You have received a warning: %s\nActive warnings: %d. Warnings expire after %s.",
+        "strike.auto_ban":      "🚫 @%s (ID: %d) was automatically banned until %s: %s.",
+        "strike.format":        "❌ Usage: /warn [ID] [reason]",
+        "strike.self":          "❌ You cannot warn yourself.",
+        "strike.warned":        "✅ @%s (ID: %d) has been warned. Active: %d.",
+        "strike.unwarn_format": "❌ Usage: /unwarn [warning_ID]",
+        "strike.not_found":     "❌ Warning #%d not found.",
+        "strike.removed":       "✅ Warning #%d (user ID: %d) has been removed.",
+        "strike.list_format":   "❌ Usage: /strikes [ID]",
+        "strike.until":         "until %s",
+        "strike.expired":       "expired",
+        "strike.none":          "ℹ️ User %d has no warnings.",
+        "strike.list":          "⚠️ Warnings for ID %d (active: %d):\n\n%s",
+
+        "audit.format": "❌ Usage: /audit [actor=@nick] [target=ID|@nick] [action=action] [from=YYYY-MM-DD] [to=YYYY-MM-DD] [page]",
+        "audit.empty":  "ℹ️ The audit log is empty.",
+        "audit.header": "📜 Audit log (page %d):\n\n",
+
+        "callout.status_pending":  "⏳ Under moderation",
+        "callout.status_new":      "🆕 New",
+        "callout.status_read":     "👁 Read",
+        "callout.status_resolved": "☑️ Resolved",
+        "callout.list_format":     "❌ Usage: /callouts [new|read|resolved|all] [page] [from=YYYY-MM-DD] [to=YYYY-MM-DD]",
+        "callout.none":            "ℹ️ No feedback.",
+        "callout.list_header":     "📬 Feedback (%s, page %d):\n\n",
+        "callout.line":            "#%d | %s | %s | @%s (ID: %d, Nickname: %s)\n%s\n",
+        "callout.reply_line":      "↪️ Reply from @%s: %s\n",
+        "callout.id_format":       "❌ Usage: %s [feedback_ID]",
+        "callout.not_found":       "❌ Feedback #%d not found.",
+        "callout.pending":         "⏳ Feedback #%d is still under moderation, review it in /modqueue first.",
+        "callout.status_set":      "✅ Feedback #%d: %s.",
+        "callout.reply_format":    "❌ Usage: /callout_reply [feedback_ID] [message]",
+        "callout.author_gone":     "⚠️ The reply was saved, but the author of feedback #%d is no longer registered.",
+        "callout.reply":           "📩 The administrators replied to your feedback:\n«%s»\n\n%s",
+        "callout.replied":         "✅ The reply to feedback #%d was sent to @%s.",
+        "callout.stats_format":    "❌ Usage: /callout_stats [weeks from 1 to 52]",
+        "callout.stats_header":    "📊 Feedback over the last %d weeks:\n\n",
+        "callout.stats_line":      "%s — %s: %d (resolved: %d)\n",
+
+        "ban.format":             "❌ Usage: /ban_user [ID] [term: 30m|12h|7d|perm] [reason|#template]",
+        "ban.bad_duration":       "❌ Invalid term '%s', examples: 30m, 12h, 7d, perm.",
+        "ban.no_template":        "❌ Reason template %s not found, see /ban_reasons.",
+        "ban.need_reason":        "❌ Specify a reason.",
+        "ban.self":               "❌ You cannot ban yourself.",
+        "ban.done":               "✅ @%s (ID: %d) has been banned %s. Reason: %s",
+        "ban.reasons_none":       "ℹ️ There are no reason templates. Add them to ban_reasons.txt.",
+        "ban.reasons":            "📋 Ban reason templates:\n\n%s\nUsage: /ban_user [ID] [term] #[number]",
+        "ban.history_format":     "❌ Usage: /ban_history [ID]",
+        "ban.active":             "active",
+        "ban.lifted":             "lifted %s (%s)",
+        "ban.history_none":       "ℹ️ @%s (ID: %d) has never been banned.",
+        "ban.history":            "📜 Ban history of @%s (ID: %d):\n\n%s",
+        "appeal.not_banned":      "ℹ️ You can only appeal while you are banned.",
+        "appeal.format":          "❌ Usage: /appeal [why the ban should be lifted]",
+        "appeal.exists":          "ℹ️ Appeal #%d for this ban has already been filed.",
+        "appeal.limit":           "❌ %d appeals against this ban have already been rejected; no more are accepted.",
+        "appeal.cooldown":        "⏳ You can file a new appeal in %s.",
+        "appeal.btn_accept":      "✅ Lift the ban",
+        "appeal.btn_reject":      "❌ Reject",
+        "appeal.card":            "⚖️ Appeal #%d from @%s (ID: %d, Nickname: %s)\nBan: %s\nBan reason: %s\n\n%s",
+        "appeal.sent":            "✅ Appeal #%d has been sent. The administrators will review it.",
+        "appeal.not_found":       "appeal #%d not found",
+        "appeal.reviewed":        "appeal #%d has already been reviewed (@%s)",
+        "appeal.comment":         "\nComment: %s",
+        "appeal.accepted":        "✅ Your appeal has been accepted and the ban lifted.%s",
+        "appeal.rejected":        "❌ Your appeal has been rejected.%s",
+        "appeal.verdict_accept":  "✅ Ban lifted",
+        "appeal.verdict_reject":  "❌ Rejected",
+        "appeal.result":          "⚖️ Appeal #%d (ID: %d)\n%s\n\n%s @%s",
+        "appeal.decision_format": "❌ Usage: %s [appeal_ID] [comment]",
+        "appeal.none":            "✅ There are no pending appeals.",
+        "appeal.list":            "⚖️ Appeals (%d):\n\n%sDecide: /appeal_accept [ID] or /appeal_reject [ID] [comment]",
+
+        "ticket.status_open":     "🟡 Open",
+        "ticket.status_answered": "🟢 Answered",
+        "ticket.status_closed":   "⚫ Closed",
+        "ticket.format":          "❌ Usage: /support [message] or /support #[ticket_ID] [message]",
+        "ticket.bad_id":          "❌ Invalid ticket ID.",
+        "ticket.not_found":       "❌ Ticket #%d not found.",
+        "ticket.closed_new":      "❌ Ticket #%d is closed. Open a new one: /support [message]",
+        "ticket.closed":          "❌ Ticket #%d is closed.",
+        "ticket.staff_followup":  "🆘 Follow-up to ticket #%d from @%s (ID: %d, Nickname: %s):\n%s\n\nReply: /reply %d [message]",
+        "ticket.staff_new":       "🆘 Ticket #%d from @%s (ID: %d, Nickname: %s):\n%s\n\nReply: /reply %d [message]\nClaim: /claim %d",
+        "ticket.added":           "✅ Your message was added to ticket #%d.",
+        "ticket.created":         "✅ Ticket #%d has been sent. Add to it: /support #%d [message]",
+        "ticket.reply_format":    "❌ Usage: /reply [ticket_ID] [message]",
+        "ticket.reply":           "📩 Support replied to ticket #%d:\n%s\n\nReply: /support #%d [message]",
+        "ticket.replied":         "✅ The reply to ticket #%d was sent to @%s (ID: %d).",
+        "ticket.list_format":     "❌ Usage: /tickets [active|open|answered|closed|mine|unassigned|all]",
+        "ticket.unassigned":      "unassigned",
+        "ticket.none":            "ℹ️ No tickets.",
+        "ticket.list":            "🎫 Tickets (%s, %d):\n\n%s\nDetails: /ticket [ID]",
+        "ticket.show_format":     "❌ Usage: /ticket [ticket_ID]",
+        "ticket.header":          "🎫 Ticket #%d\nStatus: %s\nFrom: @%s (ID: %d, Nickname: %s)\nCreated: %s\n",
+        "ticket.assignee":        "Assignee: %s\n",
+        "ticket.author_you":      "👤 You",
+        "ticket.author_support":  "🛠 Support",
+        "ticket.mine_none":       "ℹ️ You have no tickets.",
+        "ticket.mine":            "🎫 Your tickets:\n\n%s\nDetails: /ticket [ID]",
+        "ticket.claim_format":    "❌ Usage: /claim [ticket_ID]",
+        "ticket.assign_format":   "❌ Usage: /assign [ticket_ID] [@admin]",
+        "ticket.not_admin":       "❌ @%s is not an administrator.",
+        "ticket.assigned":        "✅ Ticket #%d has been assigned to @%s.",
+        "ticket.assigned_note":   "📌 @%s assigned ticket #%d to you. Details: /ticket %d",
+        "ticket.close_format":    "❌ Usage: /close_ticket [ticket_ID]",
+        "ticket.already_closed":  "ℹ️ Ticket #%d is already closed.",
+        "ticket.closed_done":     "✅ Ticket #%d has been closed.",
+        "ticket.closed_by_staff": "✅ Support has closed your ticket #%d.",
+        "ticket.closed_by_user":  "ℹ️ @%s closed ticket #%d.",
+
+        "log.rotated":           "The log file has been archived.",
+        "announce.format":       "❌ Usage: %s [to=all|users|admins|active:N|sub:category] [message]",
+        "callout.format":        "❌ Usage: /callout [feedback]",
+        "callout.empty":         "❌ Feedback cannot be empty.",
+        "callout.too_long":      "❌ The feedback is too long (max %d characters).",
+        "callout.on_moderation": "⏳ Your feedback has been sent for moderation.",
+        "callout.new":           "📢 New feedback #%d from @%s (ID: %d, Nickname: %s):\n%s\n\nReply: /callout_reply %d [message]",
+        "callout.thanks":        "✅ Thank you for your feedback!",
+
+        "banword.rejected":   "🚫 The text will be rejected, match: %s.",
+        "banword.suspicious": "⚠️ The text will go to moderation, match: %s.",
+        "banword.clean":      "✅ The text passes the filter.",
+        "banword.exists":     "❌ The word '%s' is already on the list.",
+        "banword.missing":    "❌ The word '%s' is not on the list.",
+        "banword.added":      "✅ The word '%s' has been added to the forbidden list.",
+        "banword.del_format": "❌ Usage: /delbanword [word]",
+        "banword.empty":      "❌ The word cannot be empty.",
+        "banword.deleted":    "✅ The word '%s' has been removed from the forbidden list.",
+
+        "banword.kind_word":        "🔤 Whole words",
+        "banword.kind_substring":   "🔡 Substrings",
+        "banword.kind_regex":       "🧩 Regular expressions",
+        "banword.kind_allow":       "✅ Exceptions",
+        "banword.kind_suspicious":  "⚠️ Suspicious (sent to moderation)",
+        "banword.list_empty":       "ℹ️ The forbidden word list is empty.",
+        "banword.list_header":      "🚫 Forbidden word filter (%d):\n",
+        "banword.list_hint":        "\nAdd: /banwords [substring|=word|re:pattern|+exception|?suspicious]\nTest: /banwords check [text]",
+        "banword.allow_suspicious": "an exception cannot be suspicious",
+        "banword.empty_regex":      "empty regular expression",
+        "banword.bad_regex":        "invalid regular expression: %v",
+        "banword.no_letters":       "entry '%s' contains no letters",
+
+        "offer.forbidden":         "❌ The offer contains a forbidden word: %s.",
+        "offer.text":              "Offer: %s, Price: %s",
+        "offer.on_moderation":     "⏳ Your offer for vacancy #%d has been sent for moderation.",
+        "offer.accepted":          "✅ Your offer for vacancy #%d has been accepted.",
+        "offer.note":              "✉️ Offer for vacancy #%d from %s (ID: %d): %s",
+        "admin.users_removed":     "✅ All users have been deleted.",
+        "admin.vacancies_removed": "✅ All vacancies have been deleted.",
+
+        "admin.change_id_format":   "❌ Usage: /change_id [ID] [new_ID]",
+        "admin.bad_new_id":         "❌ Invalid new ID.",
+        "admin.id_range":           "❌ The ID must be between %d and %d.",
+        "admin.id_taken":           "❌ This ID is taken.",
+        "admin.id_changed":         "✅ The ID of @%s has been changed to %d.",
+        "admin.your_id_changed":    "✅ Your ID has been changed to %d.",
+        "admin.change_nick_format": "❌ Usage: /change_nick [ID] [new_nickname]",
+        "admin.nick_changed":       "✅ The nickname of @%s has been changed to %s.",
+        "admin.delete_vac_format":  "❌ Usage: /dell_sell333 [vacancy_ID]",
+        "admin.vac_deleted":        "✅ Vacancy #%d has been deleted.",
+
+        "admin.no_users":        "ℹ️ There are no users.",
+        "admin.users_header":    "📋 Users:\n\n",
+        "admin.user_banned":     " (🚫 Banned %s, Reason: %s)",
+        "admin.no_bio":          "Not set",
+        "admin.user_inactive":   " (💤 blocked the bot)",
+        "admin.del_user_format": "❌ Usage: /del_user [ID] [reason]",
+        "admin.user_deleted":    "✅ @%s (ID: %d) has been deleted.",
+        "admin.account_deleted": "🚫 Your account has been deleted. Reason: %s",
+        "admin.unban_format":    "❌ Usage: /unban_user [ID]",
+        "admin.not_banned":      "❌ @%s (ID: %d) is not banned.",
+        "admin.unbanned":        "✅ @%s (ID: %d) has been unbanned.",
+        "admin.restart_notice":  "⚠️ The bot will restart in 10 seconds.",
+        "admin.restarting":      "✅ Restarting in 10 seconds.",
+
+        "broadcast.audience_all":     "everyone",
+        "broadcast.audience_admins":  "administrators",
+        "broadcast.audience_users":   "users without admin rights",
+        "broadcast.audience_active":  "active in the last %d days",
+        "broadcast.audience_sub":     "subscribers of «%s»",
+        "broadcast.active_format":    "usage: active:[days]",
+        "broadcast.unknown_category": "unknown category %s",
+        "broadcast.unknown_audience": "unknown audience %s",
+        "broadcast.no_recipients":    "ℹ️ The selected audience has no recipients.",
+        "broadcast.announcement":     "📢 Announcement:\n%s",
+        "broadcast.running":          "⏳ Broadcast in progress… Cancel: /broadcast_cancel %d",
+        "broadcast.finished":         "✅ Broadcast finished",
+        "broadcast.cancelled":        "⛔ Broadcast cancelled",
+        "broadcast.progress":         "📢 Broadcast #%d (%s)\n%s\n\nProcessed: %d of %d\n✅ Delivered: %d\n❌ Errors: %d\n🚫 Blocked the bot: %d\n⛔ Skipped after cancel: %d\n⏱ %s",
+        "broadcast.cancel_format":    "❌ Usage: /broadcast_cancel [broadcast_ID]",
+        "broadcast.not_found":        "❌ Active broadcast #%d not found.",
+        "broadcast.stopping":         "⛔ Broadcast #%d will be stopped.",
+        "broadcast.none":             "ℹ️ There have been no broadcasts yet.",
+        "broadcast.list_header":      "📢 Recent broadcasts:\n\n",
+    },
+}
+
+// Команды на другом языке и их основные названия
+var commandAliases = map[string]string{
+    "/помощь":      "/help",
+    "/старт":       "/start",
+    "/регистрация": "/register",
+    "/создать":     "/create",
+    "/список":      "/list",
+    "/вакансия":    "/vacancy",
+    "/мои":         "/my_vacancies",
+    "/профиль":     "/profile",
+    "/язык":        "/lang",
+    "/подтвердить": "/verify",
+    "/сдать":       "/done",
+    "/отмена":      "/cancel",
+    "/назад":       "/back",
+    "/пропустить":  "/skip",
+    "/подписаться": "/subscribe",
+    "/отписаться":  "/unsubscribe",
+    "/поддержка":   "/support",
+    "/announce":    "/Оповищения",
+    "/alerts":      "/Alerts",
+}
+
+// Перевод ключа на язык lang с подстановкой аргументов
+func T(lang string, key string, args ...interface{}) string {
+    format, ok := catalog[lang][key]
+    if !ok {
+        if format, ok = catalog[DefaultLang][key]; !ok {
+            logWarn("Нет перевода", "key", key, "lang", lang)
+            return key
+        }
+    }
+    if len(args) == 0 {
+        return format
+    }
+    return fmt.Sprintf(format, args...)
+}
+
+// Перевод с формой множественного числа для n
+func Tn(lang string, key string, n int) string {
+    forms := strings.Split(T(lang, key), "|")
+    form := forms[pluralForm(lang, n, len(forms))]
+    return fmt.Sprintf(form, n)
+}
+
+func pluralForm(lang string, n int, count int) int {
+    if n < 0 {
+        n = -n
+    }
+    form := 1
+    switch lang {
+    case LangRU:
+        switch {
+        case n%10 == 1 && n%100 != 11:
+            form = 0
+        case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+            form = 1
+        default:
+            form = 2
+        }
+    default:
+        if n == 1 {
+            form = 0
+        }
+    }
+    if form >= count {
+        form = count - 1
+    }
+    return form
+}
+
+// Перевод для чата на выбранном в нём языке
+func tr(chatID int64, key string, args ...interface{}) string {
+    return T(chatLang(chatID), key, args...)
+}
+
+func trn(chatID int64, key string, n int) string {
+    return Tn(chatLang(chatID), key, n)
+}
+
+// Язык чата: выбранный через /lang, иначе язык Telegram, иначе русский
+func chatLang(chatID int64) string {
+    if user := getUser(chatID); user != nil && user.Language != "" {
+        return user.Language
+    }
+    chatLangMutex.Lock()
+    defer chatLangMutex.Unlock()
+    if lang, ok := chatLanguages[chatID]; ok {
+        return lang
+    }
+    return DefaultLang
+}
+
+func userLang(user *User) string {
+    if user.Language != "" {
+        return user.Language
+    }
+    return chatLang(user.ChatID)
+}
+
+// Запоминание языка Telegram для чата; язык, выбранный через /lang, не перезаписывается
+func rememberLanguageCode(chatID int64, code string) {
+    if code == "" {
+        return
+    }
+    chatLangMutex.Lock()
+    if _, ok := chatLanguages[chatID]; !ok {
+        chatLanguages[chatID] = languageFromCode(code)
+    }
+    chatLangMutex.Unlock()
+}
+
+func languageFromCode(code string) string {
+    code = strings.ToLower(code)
+    for _, prefix := range []string{"ru", "uk", "be", "kk"} {
+        if strings.HasPrefix(code, prefix) {
+            return LangRU
+        }
+    }
+    return LangEN
+}
+
+// Замена локализованного названия команды основным
+func resolveCommandAlias(text string) string {
+    command, rest, _ := strings.Cut(text, " ")
+    target, ok := commandAliases[strings.ToLower(command)]
+    if !ok {
+        return text
+    }
+    if rest == "" {
+        return target
+    }
+    return target + " " + rest
+}
+
+// Длительность с учётом множественного числа: «5 минут», «1 day»
+func formatDurationFor(chatID int64, d time.Duration) string {
+    switch {
+    case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+        return trn(chatID, "days", int(d/(24*time.Hour)))
+    case d >= time.Hour && d%time.Hour == 0:
+        return trn(chatID, "hours", int(d/time.Hour))
+    }
+    return trn(chatID, "minutes", int(d/time.Minute))
+}
+
+func banUntilFor(chatID int64, user *User) string {
+    if user.BanExpires.IsZero() {
+        return tr(chatID, "ban_forever")
+    }
+    return tr(chatID, "ban_until", user.BanExpires.Format(time.DateTime))
+}
+
+// Выбор языка: /lang, /lang ru|en
+func processLangCommand(chatID int64, text string) {
+    parts := strings.Fields(text)
+    if len(parts) == 1 {
+        var row []tgbotapi.InlineKeyboardButton
+        for _, lang := range []string{LangRU, LangEN} {
+            row = append(row, tgbotapi.NewInlineKeyboardButtonData(languageNames[lang], "lang:"+lang))
+        }
+        sendMsgWithKeyboard(chatID, tr(chatID, "lang.current", languageNames[chatLang(chatID)]), tgbotapi.NewInlineKeyboardMarkup(row))
+        return
+    }
+    setLanguage(chatID, strings.ToLower(parts[1]))
+}
+
+func handleLangCallback(query *tgbotapi.CallbackQuery, args []string) {
+    if len(args) != 1 {
+        answerCallback(query.ID, tr(callbackChatID(query), "bad_button"))
+        return
+    }
+    answerCallback(query.ID, "")
+    setLanguage(callbackChatID(query), args[0])
+}
+
+func setLanguage(chatID int64, lang string) {
+    if _, ok := catalog[lang]; !ok {
+        sendMsg(chatID, tr(chatID, "lang.unknown"))
+        return
+    }
+    if user := getUser(chatID); user != nil {
+        userMutex.Lock()
+        user.Language = lang
+        saveUsers()
+        userMutex.Unlock()
+    } else {
+        chatLangMutex.Lock()
+        chatLanguages[chatID] = lang
+        chosenLanguages[chatID] = true
+        saveChatLanguages()
+        chatLangMutex.Unlock()
+    }
+    sendMsgWithKeyboard(chatID, T(lang, "lang.set", languageNames[lang]), mainMenuKeyboard(lang))
+}
+
+// Загрузка языков, выбранных до регистрации
+func loadChatLanguages() {
+    chatLangMutex.Lock()
+    defer chatLangMutex.Unlock()
+
+    file, err := os.Open(ChatLanguagesFile)
+    if err != nil {
+        return
+    }
+    defer file.Close()
+
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        parts := strings.Split(scanner.Text(), "|")
+        if len(parts) < 2 {
+            continue
+        }
+        chatID, err := strconv.ParseInt(parts[0], 10, 64)
+        if _, ok := catalog[parts[1]]; err != nil || !ok {
+            continue
+        }
+        chatLanguages[chatID] = parts[1]
+        chosenLanguages[chatID] = true
+    }
+}
+
+// Сохранение выбранных языков (вызывать под chatLangMutex). Язык Telegram не сохраняется:
+// он приходит с каждым сообщением.
+func saveChatLanguages() {
+    file, err := os.Create(ChatLanguagesFile)
+    if err != nil {
+        logError("Ошибка сохранения chat_languages.txt", "error", err)
+        return
+    }
+    defer file.Close()
+
+    for chatID := range chosenLanguages {
+        if _, err := file.WriteString(fmt.Sprintf("%d|%s\n", chatID, chatLanguages[chatID])); err != nil {
+            logError("Ошибка записи языка чата", "chat_id", chatID, "error", err)
+        }
+    }
+}
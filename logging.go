@@ -0,0 +1,249 @@
+package main
+
+import (
+    "compress/gzip"
+    "context"
+    "fmt"
+    "io"
+    "log"
+    "log/slog"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Настройки логирования
+const (
+    LogFormat          = "text" // text или json
+    LogLevel           = "info" // debug, info, warn, error
+    DebugMode          = false  // отладочный вывод Telegram API и уровень debug
+    LogMessageContents = false  // писать ли текст сообщений пользователей в лог
+    LogMaxSize         = 10 * 1024 * 1024
+    LogMaxAge          = 24 * time.Hour
+    LogMaxBackups      = 10
+)
+
+var (
+    logger    *slog.Logger
+    logWriter *rotatingWriter
+)
+
+// Файл лога с ротацией по размеру и возрасту. Старые части сжимаются в .gz.
+type rotatingWriter struct {
+    mu       sync.Mutex
+    path     string
+    file     *os.File
+    size     int64
+    openedAt time.Time
+}
+
+func newRotatingWriter(path string) (*rotatingWriter, error) {
+    w := &rotatingWriter{path: path}
+    if err := w.open(); err != nil {
+        return nil, err
+    }
+    return w, nil
+}
+
+func (w *rotatingWriter) open() error {
+    file, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+    if err != nil {
+        return err
+    }
+    info, err := file.Stat()
+    if err != nil {
+        file.Close()
+        return err
+    }
+    // Возраст части считается от открытия: ModTime меняется при каждой записи
+    w.file = file
+    w.size = info.Size()
+    w.openedAt = time.Now()
+    return nil
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    if w.size+int64(len(p)) > LogMaxSize || (w.size > 0 && time.Since(w.openedAt) > LogMaxAge) {
+        if err := w.rotateLocked(); err != nil {
+            log.Println("Ошибка ротации лога:", err)
+        }
+    }
+    n, err := w.file.Write(p)
+    w.size += int64(n)
+    return n, err
+}
+
+// Принудительная ротация
+func (w *rotatingWriter) Rotate() error {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    return w.rotateLocked()
+}
+
+func (w *rotatingWriter) rotateLocked() error {
+    if w.size == 0 {
+        return nil
+    }
+    if err := w.file.Close(); err != nil {
+        return err
+    }
+    rotated := rotatedLogName(w.path, time.Now())
+    if err := os.Rename(w.path, rotated); err != nil {
+        w.open()
+        return err
+    }
+    go compressLogFile(rotated, w.path)
+    return w.open()
+}
+
+// Имя части лога: время ротации и номер, чтобы ротации в одну секунду не
+// затирали друг друга. Номер фиксированной ширины сохраняет порядок сортировки.
+func rotatedLogName(path string, now time.Time) string {
+    ext := filepath.Ext(path)
+    base := strings.TrimSuffix(path, ext) + "-" + now.Format("20060102-150405")
+    for seq := 0; ; seq++ {
+        name := fmt.Sprintf("%s-%03d%s", base, seq, ext)
+        if _, err := os.Stat(name); err == nil {
+            continue
+        }
+        if _, err := os.Stat(name + ".gz"); err == nil {
+            continue
+        }
+        return name
+    }
+}
+
+func (w *rotatingWriter) Close() error {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    return w.file.Close()
+}
+
+// Сжатие части лога и удаление самых старых архивов
+func compressLogFile(path string, basePath string) {
+    if err := gzipFile(path); err != nil {
+        log.Println("Ошибка сжатия лога:", err)
+        return
+    }
+    ext := filepath.Ext(basePath)
+    archives, _ := filepath.Glob(strings.TrimSuffix(basePath, ext) + "-*" + ext + ".gz")
+    if len(archives) <= LogMaxBackups {
+        return
+    }
+    sort.Strings(archives)
+    for _, old := range archives[:len(archives)-LogMaxBackups] {
+        os.Remove(old)
+    }
+}
+
+func gzipFile(path string) error {
+    src, err := os.Open(path)
+    if err != nil {
+        return err
+    }
+    defer src.Close()
+    dst, err := os.Create(path + ".gz")
+    if err != nil {
+        return err
+    }
+    zw := gzip.NewWriter(dst)
+    if _, err := io.Copy(zw, src); err != nil {
+        zw.Close()
+        dst.Close()
+        return err
+    }
+    if err := zw.Close(); err != nil {
+        dst.Close()
+        return err
+    }
+    if err := dst.Close(); err != nil {
+        return err
+    }
+    src.Close()
+    return os.Remove(path)
+}
+
+// Настройка логгера
+func initLogging() {
+    var err error
+    logWriter, err = newRotatingWriter(BotLogFile)
+    if err != nil {
+        log.Fatal("Ошибка открытия лог-файла:", err)
+    }
+
+    level := slog.LevelInfo
+    switch LogLevel {
+    case "debug":
+        level = slog.LevelDebug
+    case "warn":
+        level = slog.LevelWarn
+    case "error":
+        level = slog.LevelError
+    }
+    if DebugMode {
+        level = slog.LevelDebug
+    }
+    opts := &slog.HandlerOptions{Level: level}
+    if LogFormat == "json" {
+        logger = slog.New(slog.NewJSONHandler(logWriter, opts))
+    } else {
+        logger = slog.New(slog.NewTextHandler(logWriter, opts))
+    }
+    slog.SetDefault(logger)
+}
+
+// Логирование события с явным уровнем. args — поля slog: пары ключ/значение
+// или slog.Attr ("chat_id", chatID, "user_id", userID, "error", err).
+func logInfo(message string, args ...any) {
+    logger.Log(context.Background(), slog.LevelInfo, message, args...)
+}
+
+func logWarn(message string, args ...any) {
+    logger.Log(context.Background(), slog.LevelWarn, message, args...)
+}
+
+func logError(message string, args ...any) {
+    logger.Log(context.Background(), slog.LevelError, message, args...)
+}
+
+// Логирование входящего сообщения. Текст пишется только при LogMessageContents.
+func logIncomingMessage(chatID int64, username string, text string) {
+    attrs := []any{
+        slog.Int64("chat_id", chatID),
+        slog.String("username", username),
+        slog.String("command", commandName(text)),
+    }
+    if user := getUser(chatID); user != nil {
+        attrs = append(attrs, slog.Int("user_id", user.UserID))
+    }
+    attrs = append(attrs, slog.String("text", redact(text)))
+    logger.Info("message", attrs...)
+}
+
+// Текст пользователя для логов: скрывается, если LogMessageContents выключен
+func redact(text string) string {
+    if LogMessageContents {
+        return text
+    }
+    return fmt.Sprintf("<скрыто, %d симв.>", len([]rune(text)))
+}
+
+// Название команды для логов без пользовательского текста
+func commandName(text string) string {
+    switch {
+    case strings.HasPrefix(text, "/"):
+        return strings.Fields(text)[0]
+    case strings.HasPrefix(text, "Отклик:"):
+        return "Отклик:"
+    case strings.HasPrefix(text, "!"):
+        return "!"
+    case text == "lovs":
+        return text
+    }
+    return ""
+}
@@ -2,6 +2,7 @@ package main
 
 import (
     "bufio"
+    "errors"
     "fmt"
     "log"
     "math/rand"
@@ -27,12 +28,31 @@ const (
     BotLogFile            = DataFolder + "bot.log"
     StatsLogFile          = DataFolder + "logsbot.txt"
     ForbiddenWordsFile    = DataFolder + "forbidden_words.txt"
+    TicketsFile           = DataFolder + "tickets.txt"
+    TicketMessagesFile    = DataFolder + "ticket_messages.txt"
+    ModerationFile        = DataFolder + "moderation.txt"
+    StrikesFile           = DataFolder + "strikes.txt"
+    StrikeRulesFile       = DataFolder + "strike_rules.txt"
+    JobsFile              = DataFolder + "jobs.txt"
+    ConversationsFile     = DataFolder + "conversations.txt"
+    BansFile              = DataFolder + "bans.txt"
+    AppealsFile           = DataFolder + "appeals.txt"
+    BanReasonsFile        = DataFolder + "ban_reasons.txt"
+    RateLimitsFile        = DataFolder + "rate_limits.txt"
+    AuditFile             = DataFolder + "audit.txt"
+    ChatLanguagesFile     = DataFolder + "chat_languages.txt"
     MaxUserID             = 5000
     MinUserID             = 1
     AdminUser1            = "тут админы"
     AdminUser2            = "тут 2 админ"
     VacancyExpirationDays = 7
     MaxCalloutLength      = 250
+    StrikeExpirationDays  = 30
+    MaxBanDuration        = 10 * 365 * 24 * time.Hour // дольше — только perm
+    RateLimitMuteDuration = 5 * time.Minute
+    MaxOpenVacancies      = 5
+    MaxMessageLength      = 4096
+    MainTaskQueueSize     = 1000
 )
 
 // Структуры данных
@@ -40,13 +60,18 @@ type User struct {
     Username      string
     ChatID        int64
     MinecraftNick string
-    State         string
     UserID        int
     IsBanned      bool
     BanReason     string
     BanExpires    time.Time
     Bio           string
     Location      string
+    LastActive    time.Time
+    Subscriptions []string
+    Inactive      bool      // заблокировал бота
+    Language      string    // выбранный язык интерфейса, пусто — по языку Telegram
+    Verified      bool      // ник подтверждён через сервер, см. processVerifyCommand
+    NickChangedAt time.Time // последняя смена ника, для NickChangeCooldown
 }
 
 type Vacancy struct {
@@ -60,28 +85,32 @@ type Vacancy struct {
     AcceptedBy   string
     AcceptedByID int64
     CreatedAt    time.Time
+    OnModeration bool
+    OnHold       bool   // автор заблокировал бота
+    Attachment   string // фото или документ, см. parseAttachment
+    AuthorID     int    // UserID автора, см. userrefs.go
+    AcceptorID   int    // UserID принявшего
+    Proof        string // результат работы от исполнителя, см. /done
 }
 
 type Response struct {
-    VacancyID int
-    Responder string
-    Message   string
-}
-
-type SupportMessage struct {
-    UserID    int
-    Username  string
-    Nick      string
-    Message   string
-    Timestamp time.Time
+    VacancyID   int
+    Responder   string
+    Message     string
+    Attachment  string // фото или документ к отклику
+    ResponderID int    // UserID откликнувшегося
 }
 
 type Callout struct {
+    ID        int
     UserID    int
     Username  string
     Nick      string
     Message   string
     Timestamp time.Time
+    Status    string
+    HandledBy string
+    Reply     string
 }
 
 var (
@@ -89,15 +118,12 @@ var (
     users            []User
     vacancies        []Vacancy
     responses        []Response
-    supportMessages  []SupportMessage
     callouts         []Callout
     forbiddenWords   []string
     forbiddenWordsMu sync.RWMutex
-    logFile          *os.File
     statsLogFile     *os.File
-    tempVacancies    = make(map[int64]Vacancy)
-    tempAlerts       = make(map[int64]string)
     nextVacancyID    = 1
+    nextCalloutID    = 1
     rng              = rand.New(rand.NewSource(time.Now().UnixNano()))
     banMutex         sync.Mutex
     userMutex        sync.Mutex
@@ -113,12 +139,9 @@ func initDataFolder() {
         log.Fatal("Ошибка создания папки callout:", err)
     }
 
-    var err error
-    logFile, err = os.OpenFile(BotLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-    if err != nil {
-        log.Fatal("Ошибка открытия лог-файла:", err)
-    }
+    initLogging()
 
+    var err error
     statsLogFile, err = os.OpenFile(StatsLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
     if err != nil {
         log.Fatal("Ошибка открытия файла статистики:", err)
@@ -133,15 +156,28 @@ func loadUsers() {
     }
     defer file.Close()
 
+    // Старые версии записывали пользователя при /register с пустым ником, а состояние
+    // "ожидает ник" в файл не попадало. Такие строки загружаются как есть: при
+    // следующем сообщении пользователь продолжит регистрацию, см. handleUpdate.
+    halfRegistered := 0
+    defer func() {
+        if halfRegistered > 0 {
+            logInfo("Пользователи без ника (незавершённая регистрация)", "count", halfRegistered)
+        }
+    }()
+
     scanner := bufio.NewScanner(file)
     for scanner.Scan() {
         parts := strings.Split(scanner.Text(), "|")
+        if len(parts) >= 3 && parts[2] == "" {
+            halfRegistered++
+        }
         if len(parts) >= 8 {
             chatID, _ := strconv.ParseInt(parts[1], 10, 64)
             userID, _ := strconv.Atoi(parts[3])
             isBanned, _ := strconv.ParseBool(parts[4])
             banExpires, _ := time.Parse(time.RFC3339, parts[5])
-            users = append(users, User{
+            user := User{
                 Username:      parts[0],
                 ChatID:        chatID,
                 MinecraftNick: parts[2],
@@ -150,7 +186,26 @@ func loadUsers() {
                 BanReason:     parts[6],
                 BanExpires:    banExpires,
                 Bio:           parts[7],
-            })
+            }
+            if len(parts) >= 10 {
+                user.LastActive, _ = time.Parse(time.RFC3339, parts[8])
+                if parts[9] != "" {
+                    user.Subscriptions = strings.Split(parts[9], ",")
+                }
+            }
+            if len(parts) >= 11 {
+                user.Inactive, _ = strconv.ParseBool(parts[10])
+            }
+            if len(parts) >= 12 {
+                user.Language = parts[11]
+            }
+            if len(parts) >= 13 {
+                user.Verified, _ = strconv.ParseBool(parts[12])
+            }
+            if len(parts) >= 14 && parts[13] != "" {
+                user.NickChangedAt, _ = time.Parse(time.RFC3339, parts[13])
+            }
+            users = append(users, user)
         } else if len(parts) >= 7 {
             chatID, _ := strconv.ParseInt(parts[1], 10, 64)
             userID, _ := strconv.Atoi(parts[3])
@@ -173,7 +228,7 @@ func loadUsers() {
 func saveUsers() {
     file, err := os.Create(UsersFile)
     if err != nil {
-        logToFile("❌ Ошибка сохранения users.txt: " + err.Error())
+        logError("Ошибка сохранения users.txt", "error", err)
         return
     }
     defer file.Close()
@@ -183,9 +238,17 @@ func saveUsers() {
         if !user.BanExpires.IsZero() {
             banExpiresStr = user.BanExpires.Format(time.RFC3339)
         }
-        _, err := file.WriteString(fmt.Sprintf("%s|%d|%s|%d|%t|%s|%s|%s\n", user.Username, user.ChatID, user.MinecraftNick, user.UserID, user.IsBanned, banExpiresStr, user.BanReason, user.Bio))
+        lastActiveStr := ""
+        if !user.LastActive.IsZero() {
+            lastActiveStr = user.LastActive.Format(time.RFC3339)
+        }
+        nickChangedStr := ""
+        if !user.NickChangedAt.IsZero() {
+            nickChangedStr = user.NickChangedAt.Format(time.RFC3339)
+        }
+        _, err := file.WriteString(fmt.Sprintf("%s|%d|%s|%d|%t|%s|%s|%s|%s|%s|%t|%s|%t|%s\n", user.Username, user.ChatID, user.MinecraftNick, user.UserID, user.IsBanned, banExpiresStr, user.BanReason, user.Bio, lastActiveStr, strings.Join(user.Subscriptions, ","), user.Inactive, user.Language, user.Verified, nickChangedStr))
         if err != nil {
-            logToFile("❌ Ошибка записи пользователя: " + err.Error())
+            logError("Ошибка записи пользователя", "user_id", user.UserID, "error", err)
         }
     }
 }
@@ -207,17 +270,44 @@ func loadVacancies() {
             accepted, _ := strconv.ParseBool(parts[6])
             acceptedByID, _ := strconv.ParseInt(parts[8], 10, 64)
             createdAt, _ := time.Parse(time.RFC3339, parts[9])
+            onModeration := false
+            if len(parts) >= 11 {
+                onModeration, _ = strconv.ParseBool(parts[10])
+            }
+            onHold := false
+            if len(parts) >= 12 {
+                onHold, _ = strconv.ParseBool(parts[11])
+            }
+            attachment := ""
+            if len(parts) >= 13 {
+                attachment = parts[12]
+            }
+            authorID, acceptorID := 0, 0
+            if len(parts) >= 15 {
+                authorID, _ = strconv.Atoi(parts[13])
+                acceptorID, _ = strconv.Atoi(parts[14])
+            }
+            proof := ""
+            if len(parts) >= 16 {
+                proof = unescapeLine(parts[15])
+            }
             vacancies = append(vacancies, Vacancy{
                 ID:           id,
-                Author:       parts[0],
-                Content:      parts[1],
-                Price:        parts[2],
-                PaymentInfo:  parts[3],
+                Author:       unescapeLine(parts[0]),
+                Content:      unescapeLine(parts[1]),
+                Price:        unescapeLine(parts[2]),
+                PaymentInfo:  unescapeLine(parts[3]),
                 ChatID:       chatID,
                 Accepted:     accepted,
-                AcceptedBy:   parts[7],
+                AcceptedBy:   unescapeLine(parts[7]),
                 AcceptedByID: acceptedByID,
                 CreatedAt:    createdAt,
+                OnModeration: onModeration,
+                OnHold:       onHold,
+                Attachment:   attachment,
+                AuthorID:     authorID,
+                AcceptorID:   acceptorID,
+                Proof:        proof,
             })
             if id >= nextVacancyID {
                 nextVacancyID = id + 1
@@ -226,19 +316,20 @@ func loadVacancies() {
     }
 }
 
-// Сохранение вакансий
+// Сохранение вакансий. Текстовые поля экранируются escapeField: за ними
+// идут вложение и ID, которые "|" в тексте сдвинул бы.
 func saveVacancies() {
     file, err := os.Create(VacsFile)
     if err != nil {
-        logToFile("❌ Ошибка сохранения vacancies.txt: " + err.Error())
+        logError("Ошибка сохранения vacancies.txt", "error", err)
         return
     }
     defer file.Close()
 
     for _, vac := range vacancies {
-        _, err := file.WriteString(fmt.Sprintf("%s|%s|%s|%s|%d|%d|%t|%s|%d|%s\n", vac.Author, vac.Content, vac.Price, vac.PaymentInfo, vac.ID, vac.ChatID, vac.Accepted, vac.AcceptedBy, vac.AcceptedByID, vac.CreatedAt.Format(time.RFC3339)))
+        _, err := file.WriteString(fmt.Sprintf("%s|%s|%s|%s|%d|%d|%t|%s|%d|%s|%t|%t|%s|%d|%d|%s\n", escapeField(vac.Author), escapeField(vac.Content), escapeField(vac.Price), escapeField(vac.PaymentInfo), vac.ID, vac.ChatID, vac.Accepted, escapeField(vac.AcceptedBy), vac.AcceptedByID, vac.CreatedAt.Format(time.RFC3339), vac.OnModeration, vac.OnHold, vac.Attachment, vac.AuthorID, vac.AcceptorID, escapeField(vac.Proof)))
         if err != nil {
-            logToFile("❌ Ошибка записи вакансии: " + err.Error())
+            logError("Ошибка записи вакансии", "vacancy_id", vac.ID, "error", err)
         }
     }
 }
@@ -256,28 +347,35 @@ func loadResponses() {
         parts := strings.Split(scanner.Text(), "|")
         if len(parts) >= 3 {
             vacID, _ := strconv.Atoi(parts[0])
-            responses = append(responses, Response{
+            response := Response{
                 VacancyID: vacID,
-                Responder: parts[1],
-                Message:   parts[2],
-            })
+                Responder: unescapeLine(parts[1]),
+                Message:   unescapeLine(parts[2]),
+            }
+            if len(parts) >= 4 {
+                response.Attachment = parts[3]
+            }
+            if len(parts) >= 5 {
+                response.ResponderID, _ = strconv.Atoi(parts[4])
+            }
+            responses = append(responses, response)
         }
     }
 }
 
-// Сохранение откликов
+// Сохранение откликов (текст экранируется, как в saveVacancies)
 func saveResponses() {
     file, err := os.Create(RespFile)
     if err != nil {
-        logToFile("❌ Ошибка сохранения responses.txt: " + err.Error())
+        logError("Ошибка сохранения responses.txt", "error", err)
         return
     }
     defer file.Close()
 
     for _, resp := range responses {
-        _, err := file.WriteString(fmt.Sprintf("%d|%s|%s\n", resp.VacancyID, resp.Responder, resp.Message))
+        _, err := file.WriteString(fmt.Sprintf("%d|%s|%s|%s|%d\n", resp.VacancyID, escapeField(resp.Responder), escapeField(resp.Message), resp.Attachment, resp.ResponderID))
         if err != nil {
-            logToFile("❌ Ошибка записи отклика: " + err.Error())
+            logError("Ошибка записи отклика", "vacancy_id", resp.VacancyID, "error", err)
         }
     }
 }
@@ -292,34 +390,64 @@ func loadCallouts() {
 
     scanner := bufio.NewScanner(file)
     for scanner.Scan() {
-        parts := strings.Split(scanner.Text(), "|")
-        if len(parts) >= 5 {
-            userID, _ := strconv.Atoi(parts[0])
-            timestamp, _ := time.Parse(time.RFC3339, parts[4])
-            callouts = append(callouts, Callout{
-                UserID:    userID,
-                Username:  parts[1],
-                Nick:      parts[2],
-                Message:   parts[3],
-                Timestamp: timestamp,
-            })
+        callout, ok := parseCalloutLine(scanner.Text())
+        if !ok {
+            continue
         }
+        if callout.ID == 0 {
+            callout.ID = nextCalloutID
+        }
+        if callout.ID >= nextCalloutID {
+            nextCalloutID = callout.ID + 1
+        }
+        callouts = append(callouts, callout)
     }
 }
 
+// Строка callouts.txt: userID|username|nick|message|время|id|статус|обработал|ответ.
+// Текстовые поля экранируются escapeField, чтобы "|" и переводы строк в
+// сообщении не сдвигали следующие за ним поля.
+func formatCalloutLine(callout Callout) string {
+    return fmt.Sprintf("%d|%s|%s|%s|%s|%d|%s|%s|%s", callout.UserID, escapeField(callout.Username), escapeField(callout.Nick), escapeField(callout.Message), callout.Timestamp.Format(time.RFC3339), callout.ID, callout.Status, escapeField(callout.HandledBy), escapeLine(callout.Reply))
+}
+
+func parseCalloutLine(line string) (Callout, bool) {
+    parts := strings.Split(line, "|")
+    if len(parts) < 5 {
+        return Callout{}, false
+    }
+    userID, _ := strconv.Atoi(parts[0])
+    timestamp, _ := time.Parse(time.RFC3339, parts[4])
+    callout := Callout{
+        UserID:    userID,
+        Username:  unescapeLine(parts[1]),
+        Nick:      unescapeLine(parts[2]),
+        Message:   unescapeLine(parts[3]),
+        Timestamp: timestamp,
+        Status:    CalloutStatusNew,
+    }
+    if len(parts) >= 9 {
+        callout.ID, _ = strconv.Atoi(parts[5])
+        callout.Status = parts[6]
+        callout.HandledBy = unescapeLine(parts[7])
+        callout.Reply = unescapeLine(strings.Join(parts[8:], "|"))
+    }
+    return callout, true
+}
+
 // Сохранение отзывов
 func saveCallouts() {
     file, err := os.Create(CalloutsFile)
     if err != nil {
-        logToFile("❌ Ошибка сохранения callouts.txt: " + err.Error())
+        logError("Ошибка сохранения callouts.txt", "error", err)
         return
     }
     defer file.Close()
 
     for _, callout := range callouts {
-        _, err := file.WriteString(fmt.Sprintf("%d|%s|%s|%s|%s\n", callout.UserID, callout.Username, callout.Nick, callout.Message, callout.Timestamp.Format(time.RFC3339)))
+        _, err := file.WriteString(formatCalloutLine(callout) + "\n")
         if err != nil {
-            logToFile("❌ Ошибка записи отзыва: " + err.Error())
+            logError("Ошибка записи отзыва", "callout_id", callout.ID, "error", err)
         }
     }
 }
@@ -332,7 +460,7 @@ func loadForbiddenWords() {
     if _, err := os.Stat(ForbiddenWordsFile); os.IsNotExist(err) {
         file, err := os.Create(ForbiddenWordsFile)
         if err != nil {
-            logToFile("⚠️ Не удалось создать forbidden_words.txt: " + err.Error())
+            logWarn("Не удалось создать forbidden_words.txt", "error", err)
             return
         }
         defer file.Close()
@@ -340,18 +468,19 @@ func loadForbiddenWords() {
         defaultWords := []string{"мат", "оскорбление", "дурак", "идиот"}
         for _, word := range defaultWords {
             if _, err := file.WriteString(word + "\n"); err != nil {
-                logToFile("⚠️ Ошибка записи в forbidden_words.txt: " + err.Error())
+                logWarn("Ошибка записи в forbidden_words.txt", "error", err)
                 return
             }
         }
         forbiddenWords = defaultWords
-        logToFile("✅ Создан forbidden_words.txt с начальными словами.")
+        rebuildForbiddenRules()
+        logInfo("Создан forbidden_words.txt с начальными словами")
         return
     }
 
     file, err := os.Open(ForbiddenWordsFile)
     if err != nil {
-        logToFile("⚠️ Не удалось открыть forbidden_words.txt: " + err.Error())
+        logWarn("Не удалось открыть forbidden_words.txt", "error", err)
         return
     }
     defer file.Close()
@@ -361,29 +490,48 @@ func loadForbiddenWords() {
     for scanner.Scan() {
         word := strings.TrimSpace(scanner.Text())
         if word != "" {
-            forbiddenWords = append(forbiddenWords, strings.ToLower(word))
+            forbiddenWords = append(forbiddenWords, normalizeForbiddenEntry(word))
         }
     }
     if err := scanner.Err(); err != nil {
-        logToFile("⚠️ Ошибка чтения forbidden_words.txt: " + err.Error())
+        logWarn("Ошибка чтения forbidden_words.txt", "error", err)
         return
     }
-    logToFile(fmt.Sprintf("✅ Загружено %d запрещённых слов.", len(forbiddenWords)))
+    rebuildForbiddenRules()
+    logInfo("Загружены запрещённые слова", "count", len(forbiddenWords))
+}
+
+// Приведение записи фильтра к нижнему регистру (регулярные выражения не трогаем)
+func normalizeForbiddenEntry(word string) string {
+    word = strings.TrimSpace(word)
+    if strings.HasPrefix(word, "re:") {
+        return word
+    }
+    return strings.ToLower(word)
 }
 
 // Добавление запрещённого слова
+var (
+    errForbiddenWordExists  = errors.New("слово уже в списке")
+    errForbiddenWordMissing = errors.New("слова нет в списке")
+)
+
 func addForbiddenWord(word string) error {
     forbiddenWordsMu.Lock()
     defer forbiddenWordsMu.Unlock()
 
-    word = strings.ToLower(strings.TrimSpace(word))
+    word = normalizeForbiddenEntry(word)
+    if _, err := parseForbiddenRule(word); err != nil {
+        return err
+    }
     for _, w := range forbiddenWords {
         if w == word {
-            return fmt.Errorf("слово '%s' уже в списке", word)
+            return errForbiddenWordExists
         }
     }
 
     forbiddenWords = append(forbiddenWords, word)
+    rebuildForbiddenRules()
 
     file, err := os.OpenFile(ForbiddenWordsFile, os.O_APPEND|os.O_WRONLY, 0644)
     if err != nil {
@@ -403,7 +551,7 @@ func deleteForbiddenWord(word string) error {
     forbiddenWordsMu.Lock()
     defer forbiddenWordsMu.Unlock()
 
-    word = strings.ToLower(strings.TrimSpace(word))
+    word = normalizeForbiddenEntry(word)
     foundIndex := -1
     for i, w := range forbiddenWords {
         if w == word {
@@ -412,10 +560,11 @@ func deleteForbiddenWord(word string) error {
         }
     }
     if foundIndex == -1 {
-        return fmt.Errorf("слово '%s' не найдено в списке", word)
+        return errForbiddenWordMissing
     }
 
     forbiddenWords = append(forbiddenWords[:foundIndex], forbiddenWords[foundIndex+1:]...)
+    rebuildForbiddenRules()
 
     file, err := os.Create(ForbiddenWordsFile)
     if err != nil {
@@ -437,21 +586,15 @@ func containsForbiddenWords(text string) (bool, string) {
     forbiddenWordsMu.RLock()
     defer forbiddenWordsMu.RUnlock()
 
-    textLower := strings.ToLower(text)
-    for _, word := range forbiddenWords {
-        if strings.Contains(textLower, word) {
-            return true, word
-        }
-    }
-    return false, ""
+    return matchForbiddenRules(text, false)
 }
 
-// Логирование
-func logToFile(message string) {
-    timestamp := time.Now().Format("2006-01-02 15:04:05")
-    if _, err := logFile.WriteString(fmt.Sprintf("[%s] %s\n", timestamp, message)); err != nil {
-        log.Println("Ошибка записи в лог:", err)
-    }
+// Проверка на подозрительные слова (отправка на модерацию)
+func containsSuspiciousWords(text string) (bool, string) {
+    forbiddenWordsMu.RLock()
+    defer forbiddenWordsMu.RUnlock()
+
+    return matchForbiddenRules(text, true)
 }
 
 // Логирование статистики
@@ -464,17 +607,16 @@ func logStatsToFile(message string) {
 // Очистка файла статистики
 func clearStatsLogFile() {
     if err := os.Truncate(StatsLogFile, 0); err != nil {
-        logToFile("❌ Ошибка очистки файла статистики: " + err.Error())
+        logError("Ошибка очистки файла статистики", "error", err)
     } else {
-        logToFile("🧹 Файл статистики logsbot.txt очищен.")
+        logInfo("Файл статистики logsbot.txt очищен")
     }
 }
 
-// Мониторинг системы и удаление старых вакансий
+// Мониторинг системы
 func startSystemMonitoring() {
     statsTicker := time.NewTicker(1 * time.Minute)
     clearTicker := time.NewTicker(30 * time.Minute)
-    cleanupTicker := time.NewTicker(24 * time.Hour)
 
     go func() {
         for {
@@ -483,42 +625,22 @@ func startSystemMonitoring() {
                 logSystemStats()
             case <-clearTicker.C:
                 clearStatsLogFile()
-            case <-cleanupTicker.C:
-                cleanupOldVacancies()
+                cleanupRateBuckets()
             }
         }
     }()
 }
 
-// Удаление старых вакансий
-func cleanupOldVacancies() {
-    expirationTime := time.Now().AddDate(0, 0, -VacancyExpirationDays)
-    var newVacancies []Vacancy
-    for _, vac := range vacancies {
-        if !vac.Accepted && vac.CreatedAt.Before(expirationTime) {
-            user := getUser(vac.ChatID)
-            if user != nil {
-                sendMsg(vac.ChatID, fmt.Sprintf("🗑 Вакансия #%d (%s) удалена, так как не была принята в течение %d дней.", vac.ID, vac.Content, VacancyExpirationDays))
-            }
-            logToFile(fmt.Sprintf("🗑 Удалена старая вакансия #%d от @%s (создана %s).", vac.ID, vac.Author, vac.CreatedAt.Format(time.DateTime)))
-        } else {
-            newVacancies = append(newVacancies, vac)
-        }
-    }
-    vacancies = newVacancies
-    saveVacancies()
-}
-
 // Системные метрики
 func logSystemStats() {
     var memStats runtime.MemStats
     runtime.ReadMemStats(&memStats)
 
     stats := fmt.Sprintf(
-        "[%s] Uptime: %s | HeapAlloc: %d MB | TotalAlloc: %d MB | SysMemory: %d MB | Goroutines: %d | Users: %d | Vacancies: %d | Responses: %d | Callouts: %d",
+        "[%s] Uptime: %s | HeapAlloc: %d MB | TotalAlloc: %d MB | SysMemory: %d MB | Goroutines: %d | Users: %d | Vacancies: %d | Responses: %d | Callouts: %d | Tickets: %d",
         time.Now().Format("2006-01-02 15:04:05"), time.Since(startTime).String(),
         memStats.HeapAlloc/1024/1024, memStats.TotalAlloc/1024/1024, memStats.Sys/1024/1024,
-        runtime.NumGoroutine(), len(users), len(vacancies), len(responses), len(callouts),
+        runtime.NumGoroutine(), len(users), len(vacancies), len(responses), len(callouts), len(tickets),
     )
     logStatsToFile(stats)
 }
@@ -529,128 +651,291 @@ func main() {
     loadUsers()
     loadVacancies()
     loadResponses()
+    migrateUserReferences()
     loadCallouts()
+    loadTickets()
+    loadModerationQueue()
+    loadStrikes()
+    loadJobs()
+    loadConversations()
+    loadBanHistory()
     loadForbiddenWords()
+    loadStrikeRules()
+    loadBanReasons()
+    loadRateLimits()
+    loadChatLanguages()
     startSystemMonitoring()
+    startMetricsServer()
 
     var err error
     bot, err = tgbotapi.NewBotAPI(BotToken)
     if err != nil {
         log.Fatal("Ошибка подключения к боту:", err)
     }
-    defer logFile.Close()
-    defer statsLogFile.Close()
 
-    bot.Debug = true
-    logToFile(fmt.Sprintf("🤖 Бот запущен: @%s", bot.Self.UserName))
+    bot.Debug = DebugMode
+    startSendQueue()
+    logInfo("Бот запущен", "bot", bot.Self.UserName)
+
+    ensureScheduledJobs()
+    ensureConversationJobs()
+    startScheduler()
+    watchShutdownSignals()
+    refreshVacancyGauges()
+    botReady.Store(true)
 
     updates := bot.GetUpdatesChan(tgbotapi.NewUpdate(0))
-    for update := range updates {
-        if update.Message == nil {
-            continue
+loop:
+    for {
+        select {
+        case update, ok := <-updates:
+            if !ok {
+                break loop
+            }
+            start := time.Now()
+            trackInFlight(func() { handleUpdate(update) })
+            observeUpdate(updateCommand(update), time.Since(start))
+        case task := <-mainTasks:
+            trackInFlight(task)
         }
+        refreshVacancyGauges()
+    }
+    close(mainLoopDone)
+    shutdown()
+}
 
-        chatID := update.Message.Chat.ID
-        text := update.Message.Text
-        username := update.Message.From.UserName
+// Задачи фоновых горутин выполняются в цикле обновлений: вакансии, отклики
+// и прочие данные без собственного мьютекса меняются только там
+var (
+    mainTasks    = make(chan func(), MainTaskQueueSize)
+    mainLoopDone = make(chan struct{})
+)
 
-        logToFile(fmt.Sprintf("%s: %s", username, text))
+// Передача задачи в цикл обновлений. Из самого цикла вызывать нельзя. После
+// остановки цикла задача отбрасывается.
+func runOnMain(task func()) {
+    select {
+    case mainTasks <- task:
+    case <-mainLoopDone:
+    }
+}
 
-        user := getUser(chatID)
-        if user != nil && user.IsBanned && time.Now().Before(user.BanExpires) {
-            sendMsg(chatID, fmt.Sprintf("🚫 Вы заблокированы. Причина: %s. Блокировка истекает: %s", user.BanReason, user.BanExpires.Format(time.DateTime)))
-            continue
-        } else if user != nil && user.IsBanned && time.Now().After(user.BanExpires) {
-            unbanUser(user)
-            sendMsg(chatID, "🔓 Срок вашей блокировки истек.")
-        }
+// Обработка одного обновления
+func handleUpdate(update tgbotapi.Update) {
+    if update.CallbackQuery != nil {
+        handleCallbackQuery(update.CallbackQuery)
+        return
+    }
+    if update.Message == nil {
+        return
+    }
 
-        if user != nil && user.State != "" {
-            handleUserState(chatID, update.Message, user)
-            continue
-        }
+    chatID := update.Message.Chat.ID
+    text := update.Message.Text
+    username := update.Message.From.UserName
 
-        switch {
-        case text == "/start":
-            sendMsg(chatID, "👋 Добро пожаловать! Введите /help для списка команд.")
-        case text == "/help":
-            sendHelp(chatID, username)
-        case text == "/register":
-            startRegistration(chatID, username)
-        case text == "/create":
-            startVacancyCreation(chatID, username)
-        case text == "/list_users":
-            listUsers(chatID, username)
-        case strings.HasPrefix(text, "/list"):
-            parts := strings.SplitN(text, " ", 2)
-            page := 1
-            if len(parts) == 2 {
-                page, _ = strconv.Atoi(parts[1])
-                if page < 1 {
-                    page = 1
-                }
+    logIncomingMessage(chatID, username, text)
+    rememberLanguageCode(chatID, update.Message.From.LanguageCode)
+
+    user := getUser(chatID)
+    if user != nil {
+        touchUser(user)
+    }
+    if user != nil && isBanActive(user) {
+        if strings.HasPrefix(text, "/appeal") {
+            if allowMessage(chatID, text) {
+                processAppealCommand(chatID, text)
             }
-            sendVacanciesList(chatID, page)
-        case strings.HasPrefix(text, "/Оповищения"):
-            sendAnnouncement(chatID, text, username)
-        case strings.HasPrefix(text, "/Alerts"):
-            processAlertsCommand(chatID, text, username)
-        case strings.HasPrefix(text, "/support"):
-            processSupportCommand(chatID, text, username)
-        case strings.HasPrefix(text, "/reply"):
-            processReplyCommand(chatID, text, username)
-        case strings.HasPrefix(text, "Отклик:"):
-            processResponse(chatID, text, username)
-        case strings.HasPrefix(text, "!"):
-            processAcceptOrder(chatID, text)
-        case strings.HasPrefix(text, "/chat"):
-            processChatCommand(chatID, text)
-        case strings.HasPrefix(text, "/ban_user"):
-            processBanUserCommand(chatID, text, username)
-        case text == "lovs":
-            clearLogFile()
-            sendMsg(chatID, "Лог-файл очищен.")
-        case text == "/sell_lot_poi_good22366552998":
-            removeAllVacancies(chatID, username)
-        case text == "/sell_lot_poi_good2236655299865541111976hhffrtt":
-            removeAllUsers(chatID, username)
-        case strings.HasPrefix(text, "/change_id"):
-            processChangeIDCommand(chatID, text, username)
-        case strings.HasPrefix(text, "/change_nick"):
-            processChangeNickCommand(chatID, text, username)
-        case strings.HasPrefix(text, "/dell_sell333"):
-            processDeleteVacancyCommand(chatID, text, username)
-        case text == "/profile":
-            showUserProfile(chatID)
-        case text == "/my_vacancies":
-            showMyVacancies(chatID)
-        case strings.HasPrefix(text, "/delete_vacancy"):
-            deleteMyVacancy(chatID, text)
-        case strings.HasPrefix(text, "/del_user"):
-            deleteUser(chatID, text, username)
-        case strings.HasPrefix(text, "/unban_user"):
-            unbanUserByAdmin(chatID, text, username)
-        case text == "/restart_bot":
-            restartBot(chatID, username)
-        case text == "/version":
-            showVersion(chatID)
-        case strings.HasPrefix(text, "/set_bio"):
-            processSetBioCommand(chatID, text, username)
-        case strings.HasPrefix(text, "/banwords"):
-            processBanWordsCommand(chatID, text, username)
-        case strings.HasPrefix(text, "/delbanword"):
-            processDelBanWordCommand(chatID, text, username)
-        case strings.HasPrefix(text, "/callout"):
-            processCalloutCommand(chatID, text, username)
-        default:
-            if tryProcessVacancyInfo(chatID, text) {
-                continue
+            return
+        }
+        sendMsg(chatID, tr(chatID, "banned", banUntilFor(chatID, user), user.BanReason))
+        return
+    } else if user != nil && user.IsBanned {
+        unbanUser(user, "срок истёк")
+        sendMsg(chatID, tr(chatID, "ban_expired"))
+    }
+
+    // Кнопки меню и локализованные команды приводятся к командам до проверки
+    // лимита, чтобы /callout и его синонимы расходовали один лимит
+    if command, ok := menuCommands[text]; ok {
+        text = command
+    }
+    text = resolveCommandAlias(text)
+
+    if !isAdmin(username) && !allowMessage(chatID, text) {
+        return
+    }
+
+    if conv, ok := getConversation(chatID); ok {
+        handleConversation(chatID, update.Message, text, conv)
+        return
+    }
+
+    // Регистрация без ника: запись старой версии или регистрация, прерванная /cancel
+    if user != nil && user.MinecraftNick == "" && text != "/start" && text != "/help" && !strings.HasPrefix(text, "/lang") {
+        sendMsg(chatID, tr(chatID, "register_resume"))
+        startConversation(chatID, FlowRegister)
+        return
+    }
+
+    switch {
+    case text == "/start":
+        sendMsgWithKeyboard(chatID, tr(chatID, "start"), mainMenuKeyboard(chatLang(chatID)))
+    case text == "/help":
+        sendHelp(chatID, username)
+    case text == "/register":
+        startRegistration(chatID, username)
+    case text == "/create":
+        startVacancyCreation(chatID, username)
+    case text == "/list_users":
+        listUsers(chatID, username)
+    case strings.HasPrefix(text, "/list"):
+        parts := strings.SplitN(text, " ", 2)
+        page := 1
+        if len(parts) == 2 {
+            page, _ = strconv.Atoi(parts[1])
+            if page < 1 {
+                page = 1
             }
-            sendMsg(chatID, "❌ Неизвестная команда. Введите /help.")
         }
+        sendVacanciesList(chatID, page)
+    case strings.HasPrefix(text, "/Оповищения"):
+        sendAnnouncement(chatID, text, username)
+    case strings.HasPrefix(text, "/Alerts"):
+        processAlertsCommand(chatID, text, username)
+    case strings.HasPrefix(text, "/broadcast_cancel"):
+        processBroadcastCancelCommand(chatID, text, username)
+    case text == "/broadcasts":
+        listBroadcasts(chatID, username)
+    case strings.HasPrefix(text, "/subscribe"):
+        processSubscribeCommand(chatID, text, true)
+    case strings.HasPrefix(text, "/unsubscribe"):
+        processSubscribeCommand(chatID, text, false)
+    case text == "/subscriptions":
+        showSubscriptions(chatID)
+    case strings.HasPrefix(text, "/support"):
+        processSupportCommand(chatID, text, username)
+    case strings.HasPrefix(text, "/reply"):
+        processReplyCommand(chatID, text, username)
+    case text == "/my_tickets":
+        showMyTickets(chatID)
+    case strings.HasPrefix(text, "/tickets"):
+        listTickets(chatID, text, username)
+    case strings.HasPrefix(text, "/ticket"):
+        showTicket(chatID, text, username)
+    case strings.HasPrefix(text, "/claim"):
+        processClaimTicketCommand(chatID, text, username)
+    case strings.HasPrefix(text, "/assign"):
+        processAssignTicketCommand(chatID, text, username)
+    case strings.HasPrefix(text, "/close_ticket"):
+        processCloseTicketCommand(chatID, text, username)
+    case strings.HasPrefix(text, "Отклик:"):
+        processResponse(chatID, text, username)
+    case strings.HasPrefix(text, "!"):
+        processAcceptOrder(chatID, text)
+    case strings.HasPrefix(text, "/chat"):
+        processChatCommand(chatID, text)
+    case text == "/ban_reasons":
+        showBanReasons(chatID, username)
+    case strings.HasPrefix(text, "/ban_history"):
+        showBanHistory(chatID, text, username)
+    case text == "/appeals":
+        listAppeals(chatID, username)
+    case strings.HasPrefix(text, "/appeal_accept"):
+        processAppealDecisionCommand(chatID, text, username, true)
+    case strings.HasPrefix(text, "/appeal_reject"):
+        processAppealDecisionCommand(chatID, text, username, false)
+    case strings.HasPrefix(text, "/appeal"):
+        processAppealCommand(chatID, text)
+    case strings.HasPrefix(text, "/ban_user"):
+        processBanUserCommand(chatID, text, username)
+    case text == "lovs":
+        processRotateLogCommand(chatID, username)
+    case text == "/sell_lot_poi_good22366552998":
+        removeAllVacancies(chatID, username)
+    case text == "/sell_lot_poi_good2236655299865541111976hhffrtt":
+        removeAllUsers(chatID, username)
+    case strings.HasPrefix(text, "/change_id"):
+        processChangeIDCommand(chatID, text, username)
+    case strings.HasPrefix(text, "/change_nick"):
+        processChangeNickCommand(chatID, text, username)
+    case strings.HasPrefix(text, "/vacancy"):
+        processVacancyCommand(chatID, text)
+    case strings.HasPrefix(text, "/dell_sell333"):
+        processDeleteVacancyCommand(chatID, text, username)
+    case text == "/profile":
+        showUserProfile(chatID)
+    case text == "/my_vacancies":
+        showMyVacancies(chatID)
+    case strings.HasPrefix(text, "/delete_vacancy"):
+        deleteMyVacancy(chatID, text)
+    case strings.HasPrefix(text, "/done"):
+        processDoneCommand(chatID, text)
+    case strings.HasPrefix(text, "/del_user"):
+        deleteUser(chatID, text, username)
+    case strings.HasPrefix(text, "/warn"):
+        processWarnCommand(chatID, text, username)
+    case strings.HasPrefix(text, "/audit"):
+        showAudit(chatID, text, username)
+    case strings.HasPrefix(text, "/unwarn"):
+        processUnwarnCommand(chatID, text, username)
+    case strings.HasPrefix(text, "/strikes"):
+        showStrikes(chatID, text, username)
+    case strings.HasPrefix(text, "/unban_user"):
+        unbanUserByAdmin(chatID, text, username)
+    case text == "/restart_bot":
+        restartBot(chatID, username)
+    case text == "/version":
+        showVersion(chatID)
+    case strings.HasPrefix(text, "/lang"):
+        processLangCommand(chatID, text)
+    case strings.HasPrefix(text, "/verify"):
+        processVerifyCommand(chatID, text)
+    case strings.HasPrefix(text, "/request_nick"):
+        processRequestNickCommand(chatID, text)
+    case strings.HasPrefix(text, "/set_bio"):
+        processSetBioCommand(chatID, text, username)
+    case strings.HasPrefix(text, "/banwords"):
+        processBanWordsCommand(chatID, text, username)
+    case strings.HasPrefix(text, "/delbanword"):
+        processDelBanWordCommand(chatID, text, username)
+    case text == "/modqueue":
+        showModerationQueue(chatID, username)
+    case strings.HasPrefix(text, "/approve"):
+        processModerationCommand(chatID, text, username, true)
+    case strings.HasPrefix(text, "/reject"):
+        processModerationCommand(chatID, text, username, false)
+    case strings.HasPrefix(text, "/callouts"):
+        listCallouts(chatID, text, username)
+    case strings.HasPrefix(text, "/callout_read"):
+        processCalloutStatusCommand(chatID, text, username, CalloutStatusRead)
+    case strings.HasPrefix(text, "/callout_resolve"):
+        processCalloutStatusCommand(chatID, text, username, CalloutStatusResolved)
+    case strings.HasPrefix(text, "/callout_reply"):
+        processCalloutReplyCommand(chatID, text, username)
+    case strings.HasPrefix(text, "/callout_stats"):
+        showCalloutStats(chatID, text, username)
+    case strings.HasPrefix(text, "/callout"):
+        processCalloutCommand(chatID, text, username)
+    default:
+        if tryProcessVacancyInfo(chatID, text) {
+            return
+        }
+        sendMsg(chatID, tr(chatID, "unknown_command"))
     }
 }
 
+// Команда обновления для метрик
+func updateCommand(update tgbotapi.Update) string {
+    if update.CallbackQuery != nil {
+        return "callback"
+    }
+    if update.Message == nil {
+        return "other"
+    }
+    return commandName(update.Message.Text)
+}
+
 // Получение пользователя
 func getUser(chatID int64) *User {
     userMutex.Lock()
@@ -685,141 +970,84 @@ func getUserByUsername(username string) *User {
     return nil
 }
 
-// Регистрация
-func startRegistration(chatID int64, username string) {
-    if getUser(chatID) != nil {
-        sendMsg(chatID, "❌ Вы уже зарегистрированы!")
-        return
-    }
+// Проверка прав администратора
+func isAdmin(username string) bool {
+    return username == AdminUser1 || username == AdminUser2
+}
 
-    newUser := User{
-        Username:      username,
-        ChatID:        chatID,
-        State:         "awaiting_nick",
-        MinecraftNick: "",
-        UserID:        generateUserID(),
-        Bio:           "",
+// Уведомление администраторов, каждому на его языке
+func notifyAdmins(key string, args ...interface{}) {
+    for _, admin := range []string{AdminUser1, AdminUser2} {
+        adminUser := getUserByUsername(admin)
+        if adminUser != nil {
+            sendMsg(adminUser.ChatID, tr(adminUser.ChatID, key, args...))
+        }
     }
-    userMutex.Lock()
-    users = append(users, newUser)
-    userMutex.Unlock()
-    saveUsers()
-    sendMsg(chatID, "Введите свой ник Minecraft:")
 }
 
-// Обработка состояний
-func handleUserState(chatID int64, message *tgbotapi.Message, user *User) {
-    switch user.State {
-    case "awaiting_nick":
-        if isNickTaken(message.Text) {
-            sendMsg(chatID, "❌ Этот ник уже занят.")
-            return
-        }
-        user.MinecraftNick = message.Text
-        user.State = ""
-        saveUsers()
-        sendMsg(chatID, fmt.Sprintf("✅ Регистрация завершена! Ник: %s, ID: %d", message.Text, user.UserID))
-    case "awaiting_vacancy_content":
-        if hasForbidden, word := containsForbiddenWords(message.Text); hasForbidden {
-            sendMsg(chatID, fmt.Sprintf("❌ Сообщение содержит запрещённое слово: %s.", word))
-            logToFile(fmt.Sprintf("🚫 @%s пытался использовать '%s' в вакансии.", user.Username, word))
-            return
-        }
-        tempVacancies[chatID] = Vacancy{
-            Author:      user.MinecraftNick,
-            Content:     message.Text,
-            ChatID:      chatID,
-            PaymentInfo: "",
-            CreatedAt:   time.Now(),
-        }
-        user.State = "awaiting_vacancy_price"
-        sendMsg(chatID, "2. Сколько вы предлагаете? (например, 2 алмаза)")
-    case "awaiting_vacancy_price":
-        if hasForbidden, word := containsForbiddenWords(message.Text); hasForbidden {
-            sendMsg(chatID, fmt.Sprintf("❌ Сообщение содержит запрещённое слово: %s.", word))
-            logToFile(fmt.Sprintf("🚫 @%s пытался использовать '%s' в цене.", user.Username, word))
-            return
-        }
-        if vac, ok := tempVacancies[chatID]; ok {
-            vac.Price = message.Text
-            tempVacancies[chatID] = vac
-            user.State = "awaiting_vacancy_payment"
-            sendMsg(chatID, "3. Куда и как производить оплату? (например, сундук на x:100, y:64, z:200)")
-        }
-    case "awaiting_vacancy_payment":
-        if hasForbidden, word := containsForbiddenWords(message.Text); hasForbidden {
-            sendMsg(chatID, fmt.Sprintf("❌ Сообщение содержит запрещённое слово: %s.", word))
-            logToFile(fmt.Sprintf("🚫 @%s пытался использовать '%s' в оплате.", user.Username, word))
-            return
-        }
-        if vac, ok := tempVacancies[chatID]; ok {
-            vac.PaymentInfo = message.Text
-            vac.ID = nextVacancyID
-            vacancies = append(vacancies, vac)
-            saveVacancies()
-            nextVacancyID++
-
-            notifyAllUsers(fmt.Sprintf(
-                "📢 Новая вакансия!\nОт: %s\nНужно: %s\nЦена: %s\nОплата: %s\nID: #%d",
-                vac.Author, vac.Content, vac.Price, vac.PaymentInfo, vac.ID,
-            ))
-
-            delete(tempVacancies, chatID)
-            user.State = ""
-            sendMsg(chatID, "✅ Вакансия создана!")
-        }
-    case "awaiting_alert_photo":
-        if message.Photo == nil || len(message.Photo) == 0 {
-            sendMsg(chatID, "❌ Отправьте фото.")
-            return
-        }
-        alertText, ok := tempAlerts[chatID]
-        if !ok {
-            sendMsg(chatID, "❌ Ошибка: текст объявления не найден.")
-            user.State = ""
-            return
-        }
-        photo := message.Photo[len(message.Photo)-1]
-        notifyAllUsersWithPhoto(fmt.Sprintf("📢 Объявление:\n%s", alertText), photo.FileID)
-        sendMsg(chatID, "✅ Объявление с фото отправлено.")
-        logToFile(fmt.Sprintf("Админ @%s отправил объявление с фото: %s", user.Username, alertText))
-        delete(tempAlerts, chatID)
-        user.State = ""
+// Регистрация
+func startRegistration(chatID int64, username string) {
+    if user := getUser(chatID); user != nil && user.MinecraftNick != "" {
+        sendMsg(chatID, tr(chatID, "already_registered"))
+        return
     }
+    startConversation(chatID, FlowRegister)
 }
 
+// Обработка состояний
 // Создание вакансии
 func startVacancyCreation(chatID int64, username string) {
     user := getUser(chatID)
     if user == nil {
-        sendMsg(chatID, "❌ Сначала зарегистрируйтесь (/register).")
+        sendMsg(chatID, tr(chatID, "register_first"))
         return
     }
     if user.IsBanned {
-        sendMsg(chatID, "❌ Вы заблокированы: " + user.BanReason)
+        sendMsg(chatID, tr(chatID, "banned", banUntilFor(chatID, user), user.BanReason))
         return
     }
     if user.MinecraftNick == "" {
-        sendMsg(chatID, "❌ У вас не установлен ник Minecraft.")
+        sendMsg(chatID, tr(chatID, "no_nick"))
+        return
+    }
+    if count := countOpenVacancies(chatID); count >= MaxOpenVacancies {
+        sendMsg(chatID, tr(chatID, "open_limit", count, MaxOpenVacancies))
         return
     }
-    user.State = "awaiting_vacancy_content"
-    sendMsg(chatID, "1. Что вам нужно? (например: 32 стопки мха)")
+    startConversation(chatID, FlowVacancy)
+}
+
+// Рассылка о новой вакансии
+func announceVacancy(vac Vacancy) {
+    notifyEachUser(func(lang string) (string, string, interface{}) {
+        return vacancyAnnouncementText(lang, vac), vac.Attachment, vacancyKeyboard(lang, vac)
+    })
+}
+
+func vacancyAnnouncementText(lang string, vac Vacancy) string {
+    return T(lang, "vac.announce", vacancyAuthorName(vac), vac.Content, vac.Price, vac.PaymentInfo, vac.ID)
 }
 
 // Уведомления
-func notifyAllUsers(message string) {
-    for _, user := range users {
-        sendMsg(user.ChatID, message)
-    }
+func notifyAllUsers(key string, args ...interface{}) {
+    notifyEachUser(func(lang string) (string, string, interface{}) {
+        return T(lang, key, args...), "", nil
+    })
 }
 
-func notifyAllUsersWithPhoto(message string, photoFileID string) {
+// Рассылка всем активным пользователям: render готовит текст, вложение и кнопки
+// на языке получателя
+func notifyEachUser(render func(lang string) (string, string, interface{})) {
     for _, user := range users {
-        msg := tgbotapi.NewPhoto(user.ChatID, tgbotapi.FileID(photoFileID))
-        msg.Caption = message
-        if _, err := bot.Send(msg); err != nil {
-            logToFile(fmt.Sprintf("❌ Ошибка отправки фото @%s: %s", user.Username, err.Error()))
+        if user.Inactive {
+            continue
+        }
+        chatID := user.ChatID
+        message, attachment, keyboard := render(userLang(&user))
+        for _, msg := range attachmentMessages(chatID, message, attachment, keyboard) {
+            enqueueSend(chatID, msg, SendPriorityLow, func(_ tgbotapi.Message, err error) {
+                handleSendError(chatID, err)
+            })
         }
     }
 }
@@ -827,440 +1055,592 @@ func notifyAllUsersWithPhoto(message string, photoFileID string) {
 // Объявления
 func sendAnnouncement(chatID int64, text string, username string) {
     if username != AdminUser1 && username != AdminUser2 {
-        sendMsg(chatID, "❌ У вас нет прав.")
+        sendMsg(chatID, tr(chatID, "no_rights"))
         return
     }
     parts := strings.SplitN(text, " ", 2)
     if len(parts) != 2 {
-        sendMsg(chatID, "❌ Формат: /Оповищения [сообщение]")
+        sendMsg(chatID, tr(chatID, "announce.format", "/Оповищения"))
         return
     }
-    announcement := strings.TrimSpace(parts[1])
+    audience, announcement := splitAudience(parts[1])
     if announcement == "" {
-        sendMsg(chatID, "❌ Сообщение не может быть пустым.")
+        sendMsg(chatID, tr(chatID, "empty_message"))
         return
     }
     if hasForbidden, word := containsForbiddenWords(announcement); hasForbidden {
-        sendMsg(chatID, fmt.Sprintf("❌ Сообщение содержит запрещённое слово: %s.", word))
-        logToFile(fmt.Sprintf("🚫 Админ @%s пытался использовать '%s' в объявлении.", username, word))
+        sendMsg(chatID, tr(chatID, "forbidden_word", word))
+        logWarn("Запрещённое слово в объявлении", "chat_id", chatID, "admin", username, "command", "/Оповищения", "word", word)
         return
     }
-    logToFile(fmt.Sprintf("Админ @%s отправил объявление: %s", username, announcement))
-    notifyAllUsers(fmt.Sprintf("📢 Объявление:\n%s", announcement))
-    sendMsg(chatID, "✅ Объявление отправлено.")
+    if _, _, err := resolveAudience(audience, chatLang(chatID)); err != nil {
+        sendMsg(chatID, tr(chatID, "error", err))
+        return
+    }
+    startBroadcast(chatID, username, audience, announcement, "")
 }
 
 func processAlertsCommand(chatID int64, text string, username string) {
     if username != AdminUser1 && username != AdminUser2 {
-        sendMsg(chatID, "❌ У вас нет прав.")
+        sendMsg(chatID, tr(chatID, "no_rights"))
         return
     }
     parts := strings.SplitN(text, " ", 2)
     if len(parts) != 2 {
-        sendMsg(chatID, "❌ Формат: /Alerts [сообщение]")
+        sendMsg(chatID, tr(chatID, "announce.format", "/Alerts"))
         return
     }
-    alertText := strings.TrimSpace(parts[1])
+    audience, alertText := splitAudience(parts[1])
     if alertText == "" {
-        sendMsg(chatID, "❌ Сообщение не может быть пустым.")
+        sendMsg(chatID, tr(chatID, "empty_message"))
         return
     }
     if hasForbidden, word := containsForbiddenWords(alertText); hasForbidden {
-        sendMsg(chatID, fmt.Sprintf("❌ Сообщение содержит запрещённое слово: %s.", word))
-        logToFile(fmt.Sprintf("🚫 Админ @%s пытался использовать '%s' в объявлении с фото.", username, word))
-        return
-    }
-    user := getUser(chatID)
-    if user == nil {
-        sendMsg(chatID, "❌ Вы не зарегистрированы.")
+        sendMsg(chatID, tr(chatID, "forbidden_word", word))
+        logWarn("Запрещённое слово в объявлении", "chat_id", chatID, "admin", username, "command", "/Alerts", "word", word)
         return
     }
-    tempAlerts[chatID] = alertText
-    user.State = "awaiting_alert_photo"
-    sendMsg(chatID, "📸 Отправьте фото.")
-}
-
-// Техподдержка
-func processSupportCommand(chatID int64, text string, username string) {
     user := getUser(chatID)
     if user == nil {
-        sendMsg(chatID, "❌ Зарегистрируйтесь (/register).")
-        return
-    }
-    parts := strings.SplitN(text, " ", 2)
-    if len(parts) != 2 {
-        sendMsg(chatID, "❌ Формат: /support [сообщение]")
+        sendMsg(chatID, tr(chatID, "not_registered"))
         return
     }
-    supportText := strings.TrimSpace(parts[1])
-    if supportText == "" {
-        sendMsg(chatID, "❌ Сообщение не может быть пустым.")
+    if _, _, err := resolveAudience(audience, chatLang(chatID)); err != nil {
+        sendMsg(chatID, tr(chatID, "error", err))
         return
     }
-    if hasForbidden, word := containsForbiddenWords(supportText); hasForbidden {
-        sendMsg(chatID, fmt.Sprintf("❌ Сообщение содержит запрещённое слово: %s.", word))
-        logToFile(fmt.Sprintf("🚫 @%s пытался использовать '%s' в техподдержке.", username, word))
-        return
-    }
-    supportMsg := SupportMessage{
-        UserID:    user.UserID,
-        Username:  user.Username,
-        Nick:      user.MinecraftNick,
-        Message:   supportText,
-        Timestamp: time.Now(),
-    }
-    supportMessages = append(supportMessages, supportMsg)
-
-    for _, admin := range []string{AdminUser1, AdminUser2} {
-        adminUser := getUserByUsername(admin)
-        if adminUser != nil {
-            sendMsg(adminUser.ChatID, fmt.Sprintf("🆘 Обращение от @%s (ID: %d, Ник: %s):\n%s", user.Username, user.UserID, user.MinecraftNick, supportText))
-        }
-    }
-    sendMsg(chatID, "✅ Обращение отправлено.")
-    logToFile(fmt.Sprintf("Обращение от @%s (ID: %d): %s", user.Username, user.UserID, supportText))
-}
-
-func processReplyCommand(chatID int64, text string, username string) {
-    if username != AdminUser1 && username != AdminUser2 {
-        sendMsg(chatID, "❌ У вас нет прав.")
-        return
-    }
-    parts := strings.SplitN(text, " ", 3)
-    if len(parts) != 3 {
-        sendMsg(chatID, "❌ Формат: /reply [ID_пользователя] [сообщение]")
-        return
-    }
-    targetUserID, err := strconv.Atoi(parts[1])
-    if err != nil {
-        sendMsg(chatID, "❌ Некорректный ID.")
-        return
-    }
-    replyText := strings.TrimSpace(parts[2])
-    if replyText == "" {
-        sendMsg(chatID, "❌ Сообщение не может быть пустым.")
-        return
-    }
-    targetUser := getUserByUserID(targetUserID)
-    if targetUser == nil {
-        sendMsg(chatID, fmt.Sprintf("❌ Пользователь с ID %d не найден.", targetUserID))
-        return
-    }
-    sendMsg(targetUser.ChatID, fmt.Sprintf("📩 Ответ техподдержки:\n%s", replyText))
-    sendMsg(chatID, fmt.Sprintf("✅ Ответ отправлен @%s (ID: %d).", targetUser.Username, targetUser.UserID))
-    logToFile(fmt.Sprintf("Ответ от @%s пользователю @%s (ID: %d): %s", username, targetUser.Username, targetUser.UserID, replyText))
+    startConversation(chatID, FlowAlert, audience, alertText)
 }
 
 // Обработка отзыва
 func processCalloutCommand(chatID int64, text string, username string) {
     user := getUser(chatID)
     if user == nil {
-        sendMsg(chatID, "❌ Зарегистрируйтесь (/register).")
+        sendMsg(chatID, tr(chatID, "register_first"))
         return
     }
     parts := strings.SplitN(text, " ", 2)
     if len(parts) != 2 {
-        sendMsg(chatID, "❌ Формат: /callout [отзыв]")
+        sendMsg(chatID, tr(chatID, "callout.format"))
         return
     }
     calloutText := strings.TrimSpace(parts[1])
     if calloutText == "" {
-        sendMsg(chatID, "❌ Отзыв не может быть пустым.")
+        sendMsg(chatID, tr(chatID, "callout.empty"))
         return
     }
     if len(calloutText) > MaxCalloutLength {
-        sendMsg(chatID, fmt.Sprintf("❌ Отзыв слишком длинный (макс. %d символов).", MaxCalloutLength))
+        sendMsg(chatID, tr(chatID, "callout.too_long", MaxCalloutLength))
         return
     }
+    // Частоту отзывов ограничивает общий лимит "callout", см. allowMessage
+    calloutMutex.Lock()
+    suspicious, word := containsSuspiciousWords(calloutText)
     callout := Callout{
+        ID:        nextCalloutID,
         UserID:    user.UserID,
         Username:  user.Username,
         Nick:      user.MinecraftNick,
         Message:   calloutText,
         Timestamp: time.Now(),
+        Status:    CalloutStatusNew,
+    }
+    if suspicious {
+        callout.Status = CalloutStatusPending
     }
+    nextCalloutID++
     callouts = append(callouts, callout)
     saveCallouts()
+    calloutMutex.Unlock()
+
+    if suspicious {
+        enqueueModeration(ModerationCallout, callout.ID, chatID, "@"+user.Username, calloutText, "", word)
+        sendMsg(chatID, tr(chatID, "callout.on_moderation"))
+        return
+    }
 
     for _, admin := range []string{AdminUser1, AdminUser2} {
         adminUser := getUserByUsername(admin)
         if adminUser != nil {
-            sendMsg(adminUser.ChatID, fmt.Sprintf("📢 Новый отзыв от @%s (ID: %d, Ник: %s):\n%s", user.Username, user.UserID, user.MinecraftNick, calloutText))
+            sendMsg(adminUser.ChatID, tr(adminUser.ChatID, "callout.new", callout.ID, user.Username, user.UserID, user.MinecraftNick, calloutText, callout.ID))
         }
     }
-    sendMsg(chatID, "✅ Спасибо за отзыв!")
-    logToFile(fmt.Sprintf("📢 Отзыв от @%s (ID: %d): %s", user.Username, user.UserID, calloutText))
+    sendMsg(chatID, tr(chatID, "callout.thanks"))
+    logInfo("Новый отзыв", "callout_id", callout.ID, "chat_id", chatID, "user_id", user.UserID, "username", user.Username, "text", redact(calloutText))
 }
 
 // Установка описания профиля
 func processSetBioCommand(chatID int64, text string, username string) {
     user := getUser(chatID)
     if user == nil {
-        sendMsg(chatID, "❌ Зарегистрируйтесь (/register).")
+        sendMsg(chatID, tr(chatID, "register_first"))
         return
     }
     parts := strings.SplitN(text, " ", 2)
     if len(parts) != 2 {
-        sendMsg(chatID, "❌ Формат: /set_bio [описание]")
+        sendMsg(chatID, tr(chatID, "bio.format"))
         return
     }
     bio := strings.TrimSpace(parts[1])
     if bio == "" {
-        sendMsg(chatID, "❌ Описание не может быть пустым.")
+        sendMsg(chatID, tr(chatID, "bio.empty"))
         return
     }
     if len(bio) > 100 {
-        sendMsg(chatID, "❌ Описание слишком длинное (макс. 100 символов).")
+        sendMsg(chatID, tr(chatID, "bio.too_long"))
         return
     }
     if hasForbidden, word := containsForbiddenWords(bio); hasForbidden {
-        sendMsg(chatID, fmt.Sprintf("❌ Описание содержит запрещённое слово: %s.", word))
-        logToFile(fmt.Sprintf("🚫 @%s пытался использовать '%s' в описании профиля.", username, word))
+        sendMsg(chatID, tr(chatID, "bio.forbidden", word))
+        reportForbiddenWord(chatID, word, "в описании профиля")
         return
     }
     userMutex.Lock()
     user.Bio = bio
     userMutex.Unlock()
     saveUsers()
-    sendMsg(chatID, fmt.Sprintf("✅ Описание профиля: %s", bio))
-    logToFile(fmt.Sprintf("@%s (ID: %d) обновил описание: %s", username, user.UserID, bio))
+    sendMsg(chatID, tr(chatID, "bio.set", bio))
+    logInfo("Описание обновлено", "chat_id", chatID, "user_id", user.UserID, "username", username, "command", "/set_bio", "text", redact(bio))
 }
 
 // Добавление запрещённых слов
 func processBanWordsCommand(chatID int64, text string, username string) {
     if username != AdminUser1 && username != AdminUser2 {
-        sendMsg(chatID, "❌ У вас нет прав.")
+        sendMsg(chatID, tr(chatID, "no_rights"))
         return
     }
     parts := strings.SplitN(text, " ", 2)
-    if len(parts) != 2 {
-        sendMsg(chatID, "❌ Формат: /banwords [слово]")
+    if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+        listForbiddenRules(chatID)
         return
     }
     word := strings.TrimSpace(parts[1])
-    if word == "" {
-        sendMsg(chatID, "❌ Слово не может быть пустым.")
+    if strings.HasPrefix(word, "check ") {
+        sample := strings.TrimSpace(strings.TrimPrefix(word, "check "))
+        if hasForbidden, match := containsForbiddenWords(sample); hasForbidden {
+            sendMsg(chatID, tr(chatID, "banword.rejected", match))
+        } else if suspicious, match := containsSuspiciousWords(sample); suspicious {
+            sendMsg(chatID, tr(chatID, "banword.suspicious", match))
+        } else {
+            sendMsg(chatID, tr(chatID, "banword.clean"))
+        }
         return
     }
-    if err := addForbiddenWord(word); err != nil {
-        sendMsg(chatID, fmt.Sprintf("❌ Ошибка: %s.", err))
+    if err := addForbiddenWord(word); err == errForbiddenWordExists {
+        sendMsg(chatID, tr(chatID, "banword.exists", word))
+        return
+    } else if err != nil {
+        var ruleErr *ruleError
+        if errors.As(err, &ruleErr) {
+            sendMsg(chatID, tr(chatID, "error", T(chatLang(chatID), ruleErr.key, ruleErr.args...)))
+            return
+        }
+        sendMsg(chatID, tr(chatID, "error", err))
         return
     }
-    sendMsg(chatID, fmt.Sprintf("✅ Слово '%s' добавлено в запрещённые.", word))
-    logToFile(fmt.Sprintf("Админ @%s добавил запрещённое слово: %s", username, word))
+    recordAudit("@"+username, "banword_add", "", "", word)
+    sendMsg(chatID, tr(chatID, "banword.added", word))
+    logInfo("Запрещённое слово добавлено", "chat_id", chatID, "admin", username, "command", "/banwords", "word", word)
 }
 
 // Удаление запрещённых слов
 func processDelBanWordCommand(chatID int64, text string, username string) {
     if username != AdminUser1 && username != AdminUser2 {
-        sendMsg(chatID, "❌ У вас нет прав.")
+        sendMsg(chatID, tr(chatID, "no_rights"))
         return
     }
     parts := strings.SplitN(text, " ", 2)
     if len(parts) != 2 {
-        sendMsg(chatID, "❌ Формат: /delbanword [слово]")
+        sendMsg(chatID, tr(chatID, "banword.del_format"))
         return
     }
     word := strings.TrimSpace(parts[1])
     if word == "" {
-        sendMsg(chatID, "❌ Слово не может быть пустым.")
+        sendMsg(chatID, tr(chatID, "banword.empty"))
         return
     }
-    if err := deleteForbiddenWord(word); err != nil {
-        sendMsg(chatID, fmt.Sprintf("❌ Ошибка: %s.", err))
+    if err := deleteForbiddenWord(word); err == errForbiddenWordMissing {
+        sendMsg(chatID, tr(chatID, "banword.missing", word))
+        return
+    } else if err != nil {
+        sendMsg(chatID, tr(chatID, "error", err))
         return
     }
-    sendMsg(chatID, fmt.Sprintf("✅ Слово '%s' удалено из запрещённых.", word))
-    logToFile(fmt.Sprintf("Админ @%s удалил запрещённое слово: %s", username, word))
+    recordAudit("@"+username, "banword_del", "", word, "")
+    sendMsg(chatID, tr(chatID, "banword.deleted", word))
+    logInfo("Запрещённое слово удалено", "chat_id", chatID, "admin", username, "command", "/delbanword", "word", word)
 }
 
 // Список вакансий
 func sendVacanciesList(chatID int64, page int) {
-    const itemsPerPage = 10
-    if len(vacancies) == 0 {
-        sendMsg(chatID, "ℹ️ Нет вакансий.")
-        return
-    }
-    startIndex := (page - 1) * itemsPerPage
-    if startIndex >= len(vacancies) {
-        sendMsg(chatID, fmt.Sprintf("❌ Страница %d не существует.", page))
+    text, keyboard := vacanciesListView(chatLang(chatID), page)
+    if keyboard == nil {
+        sendMsg(chatID, text)
         return
     }
-    var result strings.Builder
-    result.WriteString(fmt.Sprintf("📋 Вакансии (Страница %d):\n", page))
-    endIndex := startIndex + itemsPerPage
-    if endIndex > len(vacancies) {
-        endIndex = len(vacancies)
-    }
-    for i := startIndex; i < endIndex; i++ {
-        vac := vacancies[i]
-        acceptedStr := "❌ Не принята"
-        if vac.Accepted {
-            acceptedStr = fmt.Sprintf("✅ Принята: %s", vac.AcceptedBy)
-        }
-        paymentInfo := vac.PaymentInfo
-        if paymentInfo == "" {
-            paymentInfo = "Не указано"
-        }
-        result.WriteString(fmt.Sprintf("#%d | От: %s | Нужно: %s | Цена: %s | Оплата: %s | Статус: %s\n", vac.ID, vac.Author, vac.Content, vac.Price, paymentInfo, acceptedStr))
-    }
-    if len(vacancies) > itemsPerPage {
-        result.WriteString(fmt.Sprintf("\n📄 Показано %d-%d из %d. Используйте /list [страница].", startIndex+1, endIndex, len(vacancies)))
-    }
-    sendMsg(chatID, result.String())
+    sendMsgWithKeyboard(chatID, text, *keyboard)
 }
 
 // Отклики
 func processResponse(chatID int64, text string, responder string) {
     parts := strings.SplitN(text, ":", 2)
     if len(parts) != 2 {
-        sendMsg(chatID, "❌ Формат: 'Отклик: [ID_вакансии] [предложение]'")
+        sendMsg(chatID, tr(chatID, "resp.format"))
         return
     }
     responseParts := strings.SplitN(strings.TrimSpace(parts[1]), " ", 2)
     if len(responseParts) < 2 {
-        sendMsg(chatID, "❌ Укажите ID и текст отклика.")
+        sendMsg(chatID, tr(chatID, "resp.need_text"))
+        return
+    }
+    vacID, err := strconv.Atoi(responseParts[0])
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "resp.bad_vac_id"))
+        return
+    }
+    submitResponse(chatID, responder, vacID, responseParts[1], "")
+}
+
+// Проверка отклика фильтром и его сохранение или отправка на модерацию
+func submitResponse(chatID int64, responder string, vacID int, responseMsg string, attachment string) {
+    if !isAdmin(responder) && !allowAction(chatID, "response") {
         return
     }
-    vacIDStr := responseParts[0]
-    responseMsg := responseParts[1]
     if hasForbidden, word := containsForbiddenWords(responseMsg); hasForbidden {
-        sendMsg(chatID, fmt.Sprintf("❌ Отклик содержит запрещённое слово: %s.", word))
-        logToFile(fmt.Sprintf("🚫 @%s пытался использовать '%s' в отклике.", responder, word))
+        sendMsg(chatID, tr(chatID, "resp.forbidden", word))
+        reportForbiddenWord(chatID, word, "в отклике")
         return
     }
-    vacID, err := strconv.Atoi(vacIDStr)
-    if err != nil {
-        sendMsg(chatID, "❌ Некорректный ID вакансии.")
+    if suspicious, word := containsSuspiciousWords(responseMsg); suspicious {
+        vacancyIndex := findVacancyIndex(vacID)
+        if vacancyIndex == -1 {
+            sendMsg(chatID, tr(chatID, "vac.not_found", vacID))
+            return
+        }
+        if vacancies[vacancyIndex].Accepted {
+            sendMsg(chatID, tr(chatID, "vac.taken"))
+            return
+        }
+        enqueueModeration(ModerationResponse, vacID, chatID, responder, responseMsg, attachment, word)
+        sendMsg(chatID, tr(chatID, "resp.on_moderation", vacID))
         return
     }
-    found := false
-    var vacancyAuthorChatID int64
-    var vacancyIndex int
+    applyResponse(chatID, responder, vacID, responseMsg, attachment)
+}
+
+// Поиск опубликованной вакансии
+func findVacancyIndex(vacID int) int {
     for i, vac := range vacancies {
-        if vac.ID == vacID {
-            found = true
-            vacancyAuthorChatID = vac.ChatID
-            vacancyIndex = i
-            break
+        if vac.ID == vacID && !vac.OnModeration && !vac.OnHold {
+            return i
         }
     }
-    if !found {
-        sendMsg(chatID, fmt.Sprintf("❌ Вакансия #%d не найдена.", vacID))
+    return -1
+}
+
+// Сохранение отклика и принятие вакансии
+func applyResponse(chatID int64, responder string, vacID int, responseMsg string, attachment string) {
+    vacancyIndex := findVacancyIndex(vacID)
+    if vacancyIndex == -1 {
+        sendMsg(chatID, tr(chatID, "vac.not_found", vacID))
         return
     }
+    vacancyAuthorChatID := vacancies[vacancyIndex].ChatID
     if vacancies[vacancyIndex].Accepted {
-        sendMsg(chatID, "❌ Вакансия уже принята.")
+        sendMsg(chatID, tr(chatID, "vac.taken"))
+        return
+    }
+    responderUser := getUser(chatID)
+    if responderUser == nil {
+        sendMsg(chatID, tr(chatID, "not_registered"))
         return
     }
     response := Response{
-        VacancyID: vacID,
-        Responder: responder,
-        Message:   responseMsg,
+        VacancyID:   vacID,
+        Responder:   responderUser.MinecraftNick,
+        Message:     responseMsg,
+        Attachment:  attachment,
+        ResponderID: responderUser.UserID,
     }
     responses = append(responses, response)
     saveResponses()
-    sendMsg(chatID, fmt.Sprintf("✅ Отклик на вакансию #%d принят!", vacID))
+    sendMsg(chatID, tr(chatID, "resp.accepted", vacID))
     vacancies[vacancyIndex].Accepted = true
-    vacancies[vacancyIndex].AcceptedBy = getUser(chatID).MinecraftNick
+    vacancies[vacancyIndex].AcceptedBy = responderUser.MinecraftNick
     vacancies[vacancyIndex].AcceptedByID = chatID
+    vacancies[vacancyIndex].AcceptorID = responderUser.UserID
     saveVacancies()
     if vacancyAuthorChatID != 0 {
-        responderUser := getUser(chatID)
-        if responderUser != nil {
-            sendMsg(vacancyAuthorChatID, fmt.Sprintf("✉️ Вакансия #%d принята @%s (%s)! Связаться: /chat %d (ID: %d)", vacID, responder, responderUser.MinecraftNick, chatID, responderUser.UserID))
-        }
+        sendMsgWithAttachment(vacancyAuthorChatID, tr(vacancyAuthorChatID, "resp.author_note", vacID, responder, responderUser.MinecraftNick, responseMsg, chatID, responderUser.UserID), attachment, nil)
     }
 }
 
 // Удаление данных
 func removeAllUsers(chatID int64, username string) {
     if username != AdminUser1 && username != AdminUser2 {
-        sendMsg(chatID, "❌ У вас нет прав.")
+        sendMsg(chatID, tr(chatID, "no_rights"))
         return
     }
     userMutex.Lock()
+    count := len(users)
     users = []User{}
     userMutex.Unlock()
     saveUsers()
-    sendMsg(chatID, "✅ Все пользователи удалены.")
+    recordAudit("@"+username, "remove_all_users", "", fmt.Sprintf("%d пользователей", count), "")
+    sendMsg(chatID, tr(chatID, "admin.users_removed"))
 }
 
 func removeAllVacancies(chatID int64, username string) {
     if username != AdminUser1 && username != AdminUser2 {
-        sendMsg(chatID, "❌ У вас нет прав.")
+        sendMsg(chatID, tr(chatID, "no_rights"))
         return
     }
+    count := len(vacancies)
+    for _, vac := range vacancies {
+        cancelVacancyJobs(vac.ID)
+    }
     vacancies = []Vacancy{}
     saveVacancies()
-    sendMsg(chatID, "✅ Все вакансии удалены.")
+    recordAudit("@"+username, "remove_all_vacancies", "", fmt.Sprintf("%d вакансий", count), "")
+    sendMsg(chatID, tr(chatID, "admin.vacancies_removed"))
+}
+
+// Перенос лога в архив по команде админа
+func processRotateLogCommand(chatID int64, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    rotateLogFile()
+    recordAudit("@"+username, "rotate_log", "", "", "")
+    sendMsg(chatID, tr(chatID, "log.rotated"))
 }
 
-func clearLogFile() {
-    if err := os.Truncate(BotLogFile, 0); err != nil {
-        logToFile("❌ Ошибка очистки лога: " + err.Error())
+func rotateLogFile() {
+    if err := logWriter.Rotate(); err != nil {
+        logError("Ошибка ротации лога", "error", err)
     }
 }
 
 // Отправка сообщения
 func sendMsg(chatID int64, text string) {
-    msg := tgbotapi.NewMessage(chatID, text)
-    if _, err := bot.Send(msg); err != nil {
-        logToFile("❌ Ошибка отправки: " + err.Error())
-        if strings.Contains(err.Error(), "blocked by user") {
-            userMutex.Lock()
-            for i, user := range users {
-                if user.ChatID == chatID {
-                    users = append(users[:i], users[i+1:]...)
-                    saveUsers()
-                    logToFile(fmt.Sprintf("❌ @%s (ID: %d) удалён (заблокировал бота).", user.Username, user.UserID))
-                    break
-                }
+    for _, msg := range textMessages(chatID, text, nil) {
+        enqueueSend(chatID, msg, SendPriorityHigh, func(_ tgbotapi.Message, err error) {
+            handleSendError(chatID, err)
+        })
+    }
+}
+
+// Текст, разбитый на сообщения не длиннее лимита Telegram; keyboard — у последнего
+func textMessages(chatID int64, text string, keyboard interface{}) []tgbotapi.Chattable {
+    parts := splitMessage(text, MaxMessageLength)
+    msgs := make([]tgbotapi.Chattable, 0, len(parts))
+    for i, part := range parts {
+        msg := tgbotapi.NewMessage(chatID, part)
+        if i == len(parts)-1 {
+            msg.ReplyMarkup = keyboard
+        }
+        msgs = append(msgs, msg)
+    }
+    return msgs
+}
+
+// Разбиение текста по строкам на части не длиннее limit символов UTF-16
+// (так длину сообщения считает Telegram). Слишком длинные строки режутся.
+func splitMessage(text string, limit int) []string {
+    var parts []string
+    var current []rune
+    currentLen := 0
+    flush := func() {
+        if len(current) > 0 {
+            parts = append(parts, string(current))
+            current, currentLen = nil, 0
+        }
+    }
+    for _, line := range strings.SplitAfter(text, "\n") {
+        lineLen := utf16Len(line)
+        if currentLen+lineLen > limit {
+            flush()
+        }
+        for _, r := range line {
+            n := utf16RuneLen(r)
+            if currentLen+n > limit {
+                flush()
             }
-            userMutex.Unlock()
+            current = append(current, r)
+            currentLen += n
+        }
+    }
+    flush()
+    if len(parts) == 0 {
+        parts = append(parts, text)
+    }
+    return parts
+}
+
+func utf16Len(text string) int {
+    n := 0
+    for _, r := range text {
+        n += utf16RuneLen(r)
+    }
+    return n
+}
+
+// Символы вне базовой плоскости (эмодзи) занимают в UTF-16 две единицы
+func utf16RuneLen(r rune) int {
+    if r >= 0x10000 {
+        return 2
+    }
+    return 1
+}
+
+// Обработка ошибки доставки: пользователи, заблокировавшие бота, помечаются
+// неактивными. Вызывается из callback очереди отправки, то есть в цикле обновлений.
+func handleSendError(chatID int64, err error) {
+    if err == nil {
+        return
+    }
+    logError("Ошибка отправки", "chat_id", chatID, "error", err)
+    if isBlockedError(err) {
+        markUserInactive(chatID)
+    }
+}
+
+// Пользователь заблокировал бота: данные сохраняются, открытые вакансии замораживаются
+func markUserInactive(chatID int64) {
+    user := getUser(chatID)
+    if user == nil {
+        return
+    }
+    userMutex.Lock()
+    if user.Inactive {
+        userMutex.Unlock()
+        return
+    }
+    user.Inactive = true
+    saveUsers()
+    userMutex.Unlock()
+
+    held := 0
+    for i := range vacancies {
+        vac := &vacancies[i]
+        if vac.ChatID != chatID || vac.Accepted || vac.OnHold {
+            continue
         }
+        vac.OnHold = true
+        cancelVacancyJobs(vac.ID)
+        held++
+    }
+    if held > 0 {
+        saveVacancies()
+    }
+    logInfo("Пользователь заблокировал бота, помечен неактивным", "chat_id", chatID, "user_id", user.UserID, "username", user.Username, "held_vacancies", held)
+}
+
+// Отметка активности при любом сообщении или нажатии кнопки
+func touchUser(user *User) {
+    userMutex.Lock()
+    user.LastActive = time.Now()
+    inactive := user.Inactive
+    userMutex.Unlock()
+    if inactive {
+        reactivateUser(user)
+    }
+}
+
+// Пользователь снова написал боту: снятие отметки и возврат вакансий
+func reactivateUser(user *User) {
+    userMutex.Lock()
+    user.Inactive = false
+    saveUsers()
+    userMutex.Unlock()
+
+    resumed := 0
+    for i := range vacancies {
+        vac := &vacancies[i]
+        if vac.ChatID != user.ChatID || !vac.OnHold {
+            continue
+        }
+        vac.OnHold = false
+        expiresAt := vac.CreatedAt.AddDate(0, 0, VacancyExpirationDays)
+        if minExpiry := time.Now().Add(24 * time.Hour); expiresAt.Before(minExpiry) {
+            expiresAt = minExpiry
+        }
+        scheduleVacancyJobsAt(vac.ID, expiresAt)
+        resumed++
+    }
+    if resumed > 0 {
+        saveVacancies()
+        sendMsg(user.ChatID, tr(user.ChatID, "welcome_back", resumed))
+    }
+    logInfo("Пользователь снова активен", "chat_id", user.ChatID, "user_id", user.UserID, "username", user.Username, "resumed_vacancies", resumed)
+}
+
+// Отправка сообщения с клавиатурой
+func sendMsgWithKeyboard(chatID int64, text string, keyboard interface{}) {
+    for _, msg := range textMessages(chatID, text, keyboard) {
+        enqueueSend(chatID, msg, SendPriorityHigh, nil)
+    }
+}
+
+// Редактирование сообщения (кнопки убираются)
+func editMsg(chatID int64, messageID int, text string) {
+    enqueueSend(chatID, tgbotapi.NewEditMessageText(chatID, messageID, text), SendPriorityHigh, nil)
+}
+
+// Редактирование сообщения с новой клавиатурой
+func editMsgWithKeyboard(chatID int64, messageID int, text string, keyboard tgbotapi.InlineKeyboardMarkup) {
+    enqueueSend(chatID, tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, keyboard), SendPriorityHigh, nil)
+}
+
+// Ответ на нажатие кнопки
+func answerCallback(queryID string, text string) {
+    if _, err := bot.Request(tgbotapi.NewCallback(queryID, text)); err != nil {
+        logError("Ошибка ответа на кнопку", "error", err)
+    }
+}
+
+// Обработка нажатий inline-кнопок
+func handleCallbackQuery(query *tgbotapi.CallbackQuery) {
+    chatID := callbackChatID(query)
+    logInfo("callback", "chat_id", chatID, "username", query.From.UserName, "data", query.Data)
+    rememberLanguageCode(chatID, query.From.LanguageCode)
+    user := getUser(chatID)
+    if user != nil {
+        touchUser(user)
+    }
+    if user != nil && isBanActive(user) {
+        answerCallback(query.ID, tr(chatID, "banned_short"))
+        return
+    }
+    if !isAdmin(query.From.UserName) && !allowMessage(chatID, "") {
+        answerCallback(query.ID, tr(chatID, "too_fast"))
+        return
+    }
+    parts := strings.Split(query.Data, ":")
+    switch parts[0] {
+    case "vac":
+        handleVacancyCallback(query, parts[1:])
+    case "list":
+        handleListCallback(query, parts[1:])
+    case "conv":
+        handleConversationCallback(query, parts[1:])
+    case "lang":
+        handleLangCallback(query, parts[1:])
+    case "mod":
+        handleModerationCallback(query, parts[1:])
+    case "appeal":
+        handleAppealCallback(query, parts[1:])
+    default:
+        answerCallback(query.ID, tr(callbackChatID(query), "unknown_button"))
     }
 }
 
 // Справка
 func sendHelp(chatID int64, username string) {
-    helpText := `
-🎮 Команды бота:
-📝 /register — Зарегистрироваться
-🛠 /create — Создать вакансию
-📋 /list [страница] — Список вакансий
-📂 /my_vacancies — Ваши вакансии
-🗑 /delete_vacancy [ID] — Удалить свою вакансию
-👤 /profile — Ваш профиль
-✏️ /set_bio [описание] — Установить описание (до 100 символов)
-💬 /chat [ID_пользователя] — Начать диалог
-🆘 /support [сообщение] — Техподдержка
-📢 /callout [отзыв] — Оставить отзыв о сервере (до 250 символов)
-ℹ️ /version — Версия бота
-❓ /help — Справка
-Для принятия: ![ID_заказа]
-`
-    adminHelpText := `
-👑 Админ-команды:
-📄 /list_users — Список пользователей
-🚫 /ban_user [ID] [время_мин]мин [причина] — Забанить
-✅ /unban_user [ID] — Разбанить
-❌ /del_user [ID] [причина] — Удалить пользователя
-🔄 /change_id [ID] [новый_ID] — Изменить ID
-✏️ /change_nick [ID] [новый_ник] — Изменить ник
-🗑 /dell_sell333 [ID_вакансии] — Удалить вакансию
-📢 /Оповищения [сообщение] — Текстовое объявление
-🖼 /Alerts [сообщение] — Объявление с фото
-📩 /reply [ID_пользователя] [сообщение] — Ответ техподдержки
-🚫 /banwords [слово] — Добавить запрещённое слово
-✅ /delbanword [слово] — Удалить запрещённое слово
-🔁 /restart_bot — Перезапустить бота
-🧹 lovs — Очистить лог
-💣 sell_lot_poi_good22366552998 — Удалить все вакансии
-☠️ sell_lot_poi_good2236655299865541111976hhffrtt — Удалить всех пользователей
-`
+    helpText := tr(chatID, "help")
     if username == AdminUser1 || username == AdminUser2 {
-        sendMsg(chatID, helpText+adminHelpText)
+        sendMsg(chatID, helpText+tr(chatID, "help_admin"))
     } else {
         sendMsg(chatID, helpText)
     }
@@ -1276,82 +1656,123 @@ func tryProcessVacancyInfo(chatID int64, text string) bool {
     content := strings.TrimSpace(strings.TrimPrefix(parts[2], "Нужно: "))
     price := strings.TrimSpace(strings.TrimPrefix(parts[3], "Цена: "))
     if hasForbidden, word := containsForbiddenWords(content+" "+price); hasForbidden {
-        sendMsg(chatID, fmt.Sprintf("❌ Предложение содержит запрещённое слово: %s.", word))
-        logToFile(fmt.Sprintf("🚫 @%s пытался использовать '%s' в предложении.", getUser(chatID).Username, word))
+        sendMsg(chatID, tr(chatID, "offer.forbidden", word))
+        reportForbiddenWord(chatID, word, "в предложении")
         return true
     }
     id, err := strconv.Atoi(idStr)
     if err != nil {
-        sendMsg(chatID, "❌ Некорректный ID.")
+        sendMsg(chatID, tr(chatID, "bad_id"))
         return true
     }
-    found := false
-    var vacancyAuthorChatID int64
-    for _, vac := range vacancies {
-        if vac.ID == id {
-            found = true
-            vacancyAuthorChatID = vac.ChatID
-            break
-        }
+    vacancyIndex := findVacancyIndex(id)
+    if vacancyIndex == -1 {
+        sendMsg(chatID, tr(chatID, "vac.not_found", id))
+        return true
     }
-    if !found {
-        sendMsg(chatID, fmt.Sprintf("❌ Вакансия #%d не найдена.", id))
+    // Предложение читает автор вакансии, поэтому оно записывается на его языке
+    offer := tr(vacancies[vacancyIndex].ChatID, "offer.text", content, price)
+    if suspicious, word := containsSuspiciousWords(content + " " + price); suspicious {
+        responderUser := getUser(chatID)
+        if responderUser == nil {
+            sendMsg(chatID, tr(chatID, "not_registered"))
+            return true
+        }
+        enqueueModeration(ModerationOffer, id, chatID, responderUser.MinecraftNick, offer, "", word)
+        sendMsg(chatID, tr(chatID, "offer.on_moderation", id))
         return true
     }
+    applyOffer(chatID, id, offer)
+    return true
+}
+
+// Сохранение предложения и уведомление автора вакансии
+func applyOffer(chatID int64, id int, offer string) {
+    vacancyIndex := findVacancyIndex(id)
+    if vacancyIndex == -1 {
+        sendMsg(chatID, tr(chatID, "vac.not_found", id))
+        return
+    }
+    vacancyAuthorChatID := vacancies[vacancyIndex].ChatID
+    responderUser := getUser(chatID)
+    if responderUser == nil {
+        sendMsg(chatID, tr(chatID, "not_registered"))
+        return
+    }
     response := Response{
-        VacancyID: id,
-        Responder: getUser(chatID).MinecraftNick,
-        Message:   fmt.Sprintf("Предлагаю: %s, Цена: %s", content, price),
+        VacancyID:   id,
+        Responder:   responderUser.MinecraftNick,
+        Message:     offer,
+        ResponderID: responderUser.UserID,
     }
     responses = append(responses, response)
     saveResponses()
-    sendMsg(chatID, fmt.Sprintf("✅ Предложение по вакансии #%d принято.", id))
+    sendMsg(chatID, tr(chatID, "offer.accepted", id))
     if vacancyAuthorChatID != 0 {
-        responderUser := getUser(chatID)
-        if responderUser != nil {
-            sendMsg(vacancyAuthorChatID, fmt.Sprintf("✉️ Предложение на вакансию #%d от %s (ID: %d): %s", id, responderUser.MinecraftNick, responderUser.UserID, response.Message))
-        }
+        sendMsg(vacancyAuthorChatID, tr(vacancyAuthorChatID, "offer.note", id, responderUser.MinecraftNick, responderUser.UserID, response.Message))
     }
-    return true
 }
 
 func processAcceptOrder(chatID int64, text string) {
     vacIDStr := strings.TrimSpace(strings.TrimPrefix(text, "!"))
     vacID, err := strconv.Atoi(vacIDStr)
     if err != nil {
-        sendMsg(chatID, "❌ Некорректный ID.")
+        sendMsg(chatID, tr(chatID, "bad_id"))
         return
     }
-    found := false
-    var vacancyIndex int
-    for i, vac := range vacancies {
-        if vac.ID == vacID {
-            found = true
-            vacancyIndex = i
-            break
-        }
-    }
-    if !found {
-        sendMsg(chatID, fmt.Sprintf("❌ Вакансия #%d не найдена.", vacID))
+    vacancyIndex := findVacancyIndex(vacID)
+    if vacancyIndex == -1 {
+        sendMsg(chatID, tr(chatID, "vac.not_found", vacID))
         return
     }
     if vacancies[vacancyIndex].Accepted {
-        sendMsg(chatID, "❌ Вакансия уже принята.")
+        sendMsg(chatID, tr(chatID, "vac.taken"))
+        return
+    }
+    acceptor := getUser(chatID)
+    if acceptor == nil {
+        sendMsg(chatID, tr(chatID, "register_first"))
         return
     }
     vacancies[vacancyIndex].Accepted = true
-    vacancies[vacancyIndex].AcceptedBy = getUser(chatID).MinecraftNick
+    vacancies[vacancyIndex].AcceptedBy = acceptor.MinecraftNick
     vacancies[vacancyIndex].AcceptedByID = chatID
+    vacancies[vacancyIndex].AcceptorID = acceptor.UserID
     saveVacancies()
-    sendMsg(chatID, fmt.Sprintf("✅ Заказ #%d принят!", vacID))
-    if vacancies[vacancyIndex].ChatID != 0 {
+    sendMsg(chatID, tr(chatID, "vac.accepted", vacID, vacID))
+    if authorChatID := vacancies[vacancyIndex].ChatID; authorChatID != 0 {
         acceptorUser := getUser(chatID)
         if acceptorUser != nil {
-            sendMsg(vacancies[vacancyIndex].ChatID, fmt.Sprintf("✉️ Заказ #%d принят @%s (%s)! Связаться: /chat %d (ID: %d)", vacID, acceptorUser.Username, acceptorUser.MinecraftNick, chatID, acceptorUser.UserID))
+            sendMsg(authorChatID, tr(authorChatID, "vac.accepted_note", vacID, acceptorUser.Username, acceptorUser.MinecraftNick, chatID, acceptorUser.UserID))
         }
     }
 }
 
+// /done ID — исполнитель сдаёт работу: фото или документ уходит автору
+func processDoneCommand(chatID int64, text string) {
+    parts := strings.Fields(text)
+    if len(parts) != 2 {
+        sendMsg(chatID, tr(chatID, "proof.format"))
+        return
+    }
+    vacID, err := strconv.Atoi(strings.TrimPrefix(parts[1], "#"))
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "bad_id"))
+        return
+    }
+    vacancyIndex := findVacancyIndex(vacID)
+    if vacancyIndex == -1 {
+        sendMsg(chatID, tr(chatID, "vac.not_found", vacID))
+        return
+    }
+    user := getUser(chatID)
+    if user == nil || !vacancies[vacancyIndex].Accepted || vacancies[vacancyIndex].AcceptorID != user.UserID {
+        sendMsg(chatID, tr(chatID, "proof.not_acceptor", vacID))
+        return
+    }
+    startConversation(chatID, FlowProof, strconv.Itoa(vacID))
+}
+
 // Проверка ника
 func isNickTaken(nick string) bool {
     userMutex.Lock()
@@ -1365,187 +1786,205 @@ func isNickTaken(nick string) bool {
 }
 
 // Генерация ID
+// ID не должен совпасть с ID существующего или удалённого пользователя,
+// на которого ещё ссылаются вакансии, отклики и страйки
 func generateUserID() int {
-    return rng.Intn(MaxUserID-MinUserID+1) + MinUserID
+    id := rng.Intn(MaxUserID-MinUserID+1) + MinUserID
+    for attempt := 0; attempt < 100 && (isIDTaken(id) || isUserIDReferenced(id)); attempt++ {
+        id = rng.Intn(MaxUserID-MinUserID+1) + MinUserID
+    }
+    return id
 }
 
 // Чат
 func processChatCommand(chatID int64, text string) {
     parts := strings.SplitN(text, " ", 2)
     if len(parts) != 2 {
-        sendMsg(chatID, "❌ Формат: /chat [ID_пользователя]")
+        sendMsg(chatID, tr(chatID, "chat.format"))
         return
     }
     targetUserID, err := strconv.Atoi(parts[1])
     if err != nil {
-        sendMsg(chatID, "❌ Некорректный ID.")
+        sendMsg(chatID, tr(chatID, "bad_id"))
         return
     }
     targetUser := getUserByUserID(targetUserID)
     if targetUser == nil {
-        sendMsg(chatID, fmt.Sprintf("❌ Пользователь с ID %d не найден.", targetUserID))
+        sendMsg(chatID, tr(chatID, "chat.no_user", targetUserID))
         return
     }
     if targetUser.ChatID == chatID {
-        sendMsg(chatID, "❌ Нельзя начать чат с собой.")
+        sendMsg(chatID, tr(chatID, "chat.self"))
         return
     }
     currentUser := getUser(chatID)
     if currentUser != nil {
-        sendMsg(chatID, fmt.Sprintf("✅ Чат с @%s (ID: %d) начат! Ваш ID: %d", targetUser.Username, targetUser.UserID, currentUser.UserID))
-        sendMsg(targetUser.ChatID, fmt.Sprintf("✅ Чат начат с @%s (ID: %d)! Ваш ID: %d", currentUser.Username, currentUser.UserID, targetUser.UserID))
+        sendMsg(chatID, tr(chatID, "chat.started", targetUser.Username, targetUser.UserID, currentUser.UserID))
+        sendMsg(targetUser.ChatID, tr(targetUser.ChatID, "chat.incoming", currentUser.Username, currentUser.UserID, targetUser.UserID))
     }
 }
 
 // Бан
 func processBanUserCommand(chatID int64, text string, username string) {
     if username != AdminUser1 && username != AdminUser2 {
-        sendMsg(chatID, "❌ У вас нет прав.")
+        sendMsg(chatID, tr(chatID, "no_rights"))
         return
     }
-    parts := strings.SplitN(text, " ", 3)
-    if len(parts) != 3 {
-        sendMsg(chatID, "❌ Формат: /ban_user [ID] [время_мин]мин [причина]")
+    parts := strings.SplitN(text, " ", 4)
+    if len(parts) != 4 {
+        sendMsg(chatID, tr(chatID, "ban.format"))
         return
     }
     targetUserID, err := strconv.Atoi(parts[1])
     if err != nil {
-        sendMsg(chatID, "❌ Некорректный ID.")
+        sendMsg(chatID, tr(chatID, "bad_id"))
         return
     }
-    banDurationStr := strings.TrimSuffix(parts[2], "мин")
-    banDurationParts := strings.SplitN(banDurationStr, " ", 2)
-    if len(banDurationParts) != 2 {
-        sendMsg(chatID, "❌ Формат времени: [время_мин]мин [причина]")
+    banDuration, ok := parseBanDuration(parts[2])
+    if !ok {
+        sendMsg(chatID, tr(chatID, "ban.bad_duration", parts[2]))
         return
     }
-    banDurationMinutes, err := strconv.Atoi(banDurationParts[0])
-    if err != nil {
-        sendMsg(chatID, "❌ Некорректное время.")
+    banReason, ok := resolveBanReason(parts[3])
+    if !ok {
+        sendMsg(chatID, tr(chatID, "ban.no_template", strings.TrimSpace(parts[3])))
         return
     }
-    banReason := strings.TrimSpace(banDurationParts[1])
     if banReason == "" {
-        sendMsg(chatID, "❌ Укажите причину.")
+        sendMsg(chatID, tr(chatID, "ban.need_reason"))
         return
     }
     targetUser := getUserByUserID(targetUserID)
     if targetUser == nil {
-        sendMsg(chatID, fmt.Sprintf("❌ Пользователь с ID %d не найден.", targetUserID))
+        sendMsg(chatID, tr(chatID, "user_not_found", targetUserID))
         return
     }
     if targetUser.ChatID == chatID {
-        sendMsg(chatID, "❌ Нельзя забанить себя.")
+        sendMsg(chatID, tr(chatID, "ban.self"))
         return
     }
+    banUser(targetUser, banDuration, banReason, "@"+username)
+    sendMsg(chatID, tr(chatID, "ban.done", targetUser.Username, targetUser.UserID, banUntilFor(chatID, targetUser), banReason))
+}
+
+// Блокировка пользователя на заданный срок (duration <= 0 — навсегда)
+func banUser(targetUser *User, duration time.Duration, banReason string, issuedBy string) {
+    before := ""
+    if targetUser.IsBanned {
+        before = fmt.Sprintf("бан %s: %s", banUntilText(targetUser), targetUser.BanReason)
+        recordUnban(targetUser.ChatID, "заменён новым баном")
+    }
     banMutex.Lock()
     targetUser.IsBanned = true
     targetUser.BanReason = banReason
-    targetUser.BanExpires = time.Now().Add(time.Duration(banDurationMinutes) * time.Minute)
+    targetUser.BanExpires = time.Time{}
+    if duration > 0 {
+        targetUser.BanExpires = time.Now().Add(duration)
+    }
     banMutex.Unlock()
     saveUsers()
-    sendMsg(chatID, fmt.Sprintf("✅ @%s (ID: %d) забанен на %d минут. Причина: %s", targetUser.Username, targetUser.UserID, banDurationMinutes, banReason))
-    sendMsg(targetUser.ChatID, fmt.Sprintf("🚫 Вы забанены на %d минут. Причина: %s", banDurationMinutes, banReason))
-    go func(user *User, durationMinutes int) {
-        time.Sleep(time.Duration(durationMinutes) * time.Minute)
-        banMutex.Lock()
-        if user.IsBanned && time.Now().After(user.BanExpires) {
-            user.IsBanned = false
-            user.BanReason = ""
-            user.BanExpires = time.Time{}
-            saveUsers()
-            banMutex.Unlock()
-            sendMsg(user.ChatID, "✅ Вы разблокированы.")
-            logToFile(fmt.Sprintf("@%s (ID: %d) разблокирован.", user.Username, user.UserID))
-        } else {
-            banMutex.Unlock()
-        }
-    }(targetUser, banDurationMinutes)
+    recordBan(targetUser, banReason, issuedBy)
+    sendMsg(targetUser.ChatID, tr(targetUser.ChatID, "banned", banUntilFor(targetUser.ChatID, targetUser), banReason))
+    if duration > 0 {
+        scheduleJob(JobBanExpiry, targetUser.ChatID, targetUser.BanExpires, "")
+    } else {
+        cancelJobs(JobBanExpiry, targetUser.ChatID)
+    }
+    recordAudit(issuedBy, "ban", auditUser(targetUser), before, fmt.Sprintf("бан %s: %s", banUntilText(targetUser), banReason))
+    logInfo("Пользователь забанен", "chat_id", targetUser.ChatID, "user_id", targetUser.UserID, "username", targetUser.Username, "until", banUntilText(targetUser), "issued_by", issuedBy, "reason", redact(banReason))
 }
 
-func unbanUser(user *User) {
+func unbanUser(user *User, liftedBy string) {
     banMutex.Lock()
     defer banMutex.Unlock()
+    recordAudit(liftedBy, "unban", auditUser(user), fmt.Sprintf("бан %s: %s", banUntilText(user), user.BanReason), "")
     user.IsBanned = false
     user.BanReason = ""
     user.BanExpires = time.Time{}
     saveUsers()
-    logToFile(fmt.Sprintf("@%s (ID: %d) разблокирован.", user.Username, user.UserID))
+    cancelJobs(JobBanExpiry, user.ChatID)
+    recordUnban(user.ChatID, liftedBy)
+    logInfo("Пользователь разблокирован", "chat_id", user.ChatID, "user_id", user.UserID, "username", user.Username, "lifted_by", liftedBy)
 }
 
 // Изменение ID и ника
 func processChangeIDCommand(chatID int64, text string, username string) {
     if username != AdminUser1 && username != AdminUser2 {
-        sendMsg(chatID, "❌ У вас нет прав.")
+        sendMsg(chatID, tr(chatID, "no_rights"))
         return
     }
     parts := strings.SplitN(text, " ", 3)
     if len(parts) != 3 {
-        sendMsg(chatID, "❌ Формат: /change_id [ID] [новый_ID]")
+        sendMsg(chatID, tr(chatID, "admin.change_id_format"))
         return
     }
     targetUserID, err := strconv.Atoi(parts[1])
     if err != nil {
-        sendMsg(chatID, "❌ Некорректный ID.")
+        sendMsg(chatID, tr(chatID, "bad_id"))
         return
     }
     newUserID, err := strconv.Atoi(parts[2])
     if err != nil {
-        sendMsg(chatID, "❌ Некорректный новый ID.")
+        sendMsg(chatID, tr(chatID, "admin.bad_new_id"))
         return
     }
     if newUserID < MinUserID || newUserID > MaxUserID {
-        sendMsg(chatID, fmt.Sprintf("❌ ID должен быть от %d до %d.", MinUserID, MaxUserID))
+        sendMsg(chatID, tr(chatID, "admin.id_range", MinUserID, MaxUserID))
         return
     }
     targetUser := getUserByUserID(targetUserID)
     if targetUser == nil {
-        sendMsg(chatID, fmt.Sprintf("❌ Пользователь с ID %d не найден.", targetUserID))
+        sendMsg(chatID, tr(chatID, "user_not_found", targetUserID))
         return
     }
-    if isIDTaken(newUserID) {
-        sendMsg(chatID, "❌ Этот ID занят.")
+    if isIDTaken(newUserID) || isUserIDReferenced(newUserID) {
+        sendMsg(chatID, tr(chatID, "admin.id_taken"))
         return
     }
     userMutex.Lock()
     targetUser.UserID = newUserID
     userMutex.Unlock()
     saveUsers()
-    sendMsg(chatID, fmt.Sprintf("✅ ID @%s изменён на %d.", targetUser.Username, newUserID))
-    sendMsg(targetUser.ChatID, fmt.Sprintf("✅ Ваш ID изменён на %d.", newUserID))
+    remapUserID(targetUserID, newUserID)
+    recordAudit("@"+username, "change_id", fmt.Sprintf("ID %d (@%s)", targetUserID, targetUser.Username), strconv.Itoa(targetUserID), strconv.Itoa(newUserID))
+    sendMsg(chatID, tr(chatID, "admin.id_changed", targetUser.Username, newUserID))
+    sendMsg(targetUser.ChatID, tr(targetUser.ChatID, "admin.your_id_changed", newUserID))
 }
 
 func processChangeNickCommand(chatID int64, text string, username string) {
     if username != AdminUser1 && username != AdminUser2 {
-        sendMsg(chatID, "❌ У вас нет прав.")
+        sendMsg(chatID, tr(chatID, "no_rights"))
         return
     }
     parts := strings.SplitN(text, " ", 3)
     if len(parts) != 3 {
-        sendMsg(chatID, "❌ Формат: /change_nick [ID] [новый_ник]")
+        sendMsg(chatID, tr(chatID, "admin.change_nick_format"))
         return
     }
     targetUserID, err := strconv.Atoi(parts[1])
     if err != nil {
-        sendMsg(chatID, "❌ Некорректный ID.")
+        sendMsg(chatID, tr(chatID, "bad_id"))
+        return
+    }
+    newNick := strings.TrimSpace(parts[2])
+    if errText := validateNick(chatLang(chatID), newNick); errText != "" {
+        sendMsg(chatID, errText)
         return
     }
-    newNick := parts[2]
     targetUser := getUserByUserID(targetUserID)
     if targetUser == nil {
-        sendMsg(chatID, fmt.Sprintf("❌ Пользователь с ID %d не найден.", targetUserID))
+        sendMsg(chatID, tr(chatID, "user_not_found", targetUserID))
         return
     }
     if isNickTaken(newNick) {
-        sendMsg(chatID, "❌ Ник занят.")
+        sendMsg(chatID, tr(chatID, "nick_taken"))
         return
     }
-    userMutex.Lock()
-    targetUser.MinecraftNick = newNick
-    userMutex.Unlock()
-    saveUsers()
-    sendMsg(chatID, fmt.Sprintf("✅ Ник @%s изменён на %s.", targetUser.Username, newNick))
-    sendMsg(targetUser.ChatID, fmt.Sprintf("✅ Ваш ник изменён на %s.", newNick))
+    oldNick := targetUser.MinecraftNick
+    changeNick(targetUser, newNick)
+    recordAudit("@"+username, "change_nick", auditUser(targetUser), oldNick, newNick)
+    sendMsg(chatID, tr(chatID, "admin.nick_changed", targetUser.Username, newNick))
+    sendMsg(targetUser.ChatID, tr(targetUser.ChatID, "nick.changed", newNick))
 }
 
 func isIDTaken(id int) bool {
@@ -1562,17 +2001,17 @@ func isIDTaken(id int) bool {
 // Удаление вакансий
 func processDeleteVacancyCommand(chatID int64, text string, username string) {
     if username != AdminUser1 && username != AdminUser2 {
-        sendMsg(chatID, "❌ У вас нет прав.")
+        sendMsg(chatID, tr(chatID, "no_rights"))
         return
     }
     parts := strings.SplitN(text, " ", 2)
     if len(parts) != 2 {
-        sendMsg(chatID, "❌ Формат: /dell_sell333 [ID_вакансии]")
+        sendMsg(chatID, tr(chatID, "admin.delete_vac_format"))
         return
     }
     vacancyIDToDelete, err := strconv.Atoi(parts[1])
     if err != nil {
-        sendMsg(chatID, "❌ Некорректный ID.")
+        sendMsg(chatID, tr(chatID, "bad_id"))
         return
     }
     foundIndex := -1
@@ -1583,11 +2022,14 @@ func processDeleteVacancyCommand(chatID int64, text string, username string) {
         }
     }
     if foundIndex != -1 {
+        removed := vacancies[foundIndex]
         vacancies = append(vacancies[:foundIndex], vacancies[foundIndex+1:]...)
         saveVacancies()
-        sendMsg(chatID, fmt.Sprintf("✅ Вакансия #%d удалена.", vacancyIDToDelete))
+        cancelVacancyJobs(removed.ID)
+        recordAudit("@"+username, "delete_vacancy", fmt.Sprintf("#%d", removed.ID), fmt.Sprintf("%s | %s | %s", vacancyAuthorName(removed), removed.Content, removed.Price), "")
+        sendMsg(chatID, tr(chatID, "admin.vac_deleted", vacancyIDToDelete))
     } else {
-        sendMsg(chatID, fmt.Sprintf("❌ Вакансия #%d не найдена.", vacancyIDToDelete))
+        sendMsg(chatID, tr(chatID, "vac.not_found", vacancyIDToDelete))
     }
 }
 
@@ -1595,21 +2037,20 @@ func processDeleteVacancyCommand(chatID int64, text string, username string) {
 func showUserProfile(chatID int64) {
     user := getUser(chatID)
     if user == nil {
-        sendMsg(chatID, "❌ Вы не зарегистрированы.")
+        sendMsg(chatID, tr(chatID, "not_registered"))
         return
     }
     bio := user.Bio
     if bio == "" {
-        bio = "Не указано"
+        bio = tr(chatID, "vac.not_set")
     }
-    profile := fmt.Sprintf(
-        "📌 Профиль:\n🆔 ID: %d\n👤 Ник: %s\n📛 @%s\n📝 Описание: %s\n%s\n📅 Регистрация: %s",
-        user.UserID, user.MinecraftNick, user.Username, bio,
+    profile := tr(chatID, "profile",
+        user.UserID, user.MinecraftNick+nickBadge(user), user.Username, bio, countActiveStrikes(user.UserID),
         func() string {
             if user.IsBanned {
-                return fmt.Sprintf("🚫 Забанен до %s\n📝 Причина: %s", user.BanExpires.Format(time.DateTime), user.BanReason)
+                return tr(chatID, "profile.banned", banUntilFor(chatID, user), user.BanReason)
             }
-            return "✅ Активен"
+            return tr(chatID, "profile.active")
         }(),
         time.Now().Format("02.01.2006"),
     )
@@ -1620,31 +2061,35 @@ func showUserProfile(chatID int64) {
 func showMyVacancies(chatID int64) {
     user := getUser(chatID)
     if user == nil {
-        sendMsg(chatID, "❌ Вы не зарегистрированы.")
+        sendMsg(chatID, tr(chatID, "not_registered"))
         return
     }
     var myVacancies []Vacancy
     for _, vac := range vacancies {
-        if vac.Author == user.MinecraftNick {
+        if vacancyBelongsTo(vac, user.UserID, user.ChatID) {
             myVacancies = append(myVacancies, vac)
         }
     }
     if len(myVacancies) == 0 {
-        sendMsg(chatID, "ℹ️ У вас нет вакансий.")
+        sendMsg(chatID, tr(chatID, "my.none"))
         return
     }
     var sb strings.Builder
-    sb.WriteString("📋 Ваши вакансии:\n\n")
+    sb.WriteString(tr(chatID, "my.header", trn(chatID, "vac.count", len(myVacancies))))
     for _, vac := range myVacancies {
-        status := "🟢 Активна"
-        if vac.Accepted {
-            status = fmt.Sprintf("✅ Принята: %s", vac.AcceptedBy)
+        status := tr(chatID, "my.active")
+        if vac.OnModeration {
+            status = tr(chatID, "vac.moderation")
+        } else if vac.OnHold {
+            status = tr(chatID, "vac.hold")
+        } else if vac.Accepted {
+            status = tr(chatID, "vac.accepted_by", vacancyAcceptorName(vac))
         }
         paymentInfo := vac.PaymentInfo
         if paymentInfo == "" {
-            paymentInfo = "Не указано"
+            paymentInfo = tr(chatID, "vac.not_set")
         }
-        sb.WriteString(fmt.Sprintf("#%d | %s | %s | Оплата: %s | %s\n", vac.ID, vac.Content, vac.Price, paymentInfo, status))
+        sb.WriteString(tr(chatID, "my.line", vac.ID, vac.Content, vac.Price, paymentInfo, status))
     }
     sendMsg(chatID, sb.String())
 }
@@ -1653,146 +2098,159 @@ func showMyVacancies(chatID int64) {
 func deleteMyVacancy(chatID int64, text string) {
     user := getUser(chatID)
     if user == nil {
-        sendMsg(chatID, "❌ Вы не зарегистрированы.")
+        sendMsg(chatID, tr(chatID, "not_registered"))
         return
     }
     parts := strings.SplitN(text, " ", 2)
     if len(parts) != 2 {
-        sendMsg(chatID, "❌ Формат: /delete_vacancy [ID]")
+        sendMsg(chatID, tr(chatID, "my.format"))
         return
     }
     vacID, err := strconv.Atoi(parts[1])
     if err != nil {
-        sendMsg(chatID, "❌ Некорректный ID.")
+        sendMsg(chatID, tr(chatID, "bad_id"))
         return
     }
     foundIndex := -1
     for i, vac := range vacancies {
-        if vac.ID == vacID && vac.Author == user.MinecraftNick {
+        if vac.ID == vacID && vacancyBelongsTo(vac, user.UserID, user.ChatID) {
             foundIndex = i
             break
         }
     }
     if foundIndex == -1 {
-        sendMsg(chatID, "❌ Вакансия не найдена или не ваша.")
+        sendMsg(chatID, tr(chatID, "my.not_own"))
         return
     }
     vacancies = append(vacancies[:foundIndex], vacancies[foundIndex+1:]...)
     saveVacancies()
-    sendMsg(chatID, fmt.Sprintf("✅ Вакансия #%d удалена.", vacID))
+    cancelVacancyJobs(vacID)
+    sendMsg(chatID, tr(chatID, "my.deleted", vacID))
 }
 
 // Список пользователей
 func listUsers(chatID int64, username string) {
     if username != AdminUser1 && username != AdminUser2 {
-        sendMsg(chatID, "❌ У вас нет прав.")
+        sendMsg(chatID, tr(chatID, "no_rights"))
         return
     }
     if len(users) == 0 {
-        sendMsg(chatID, "ℹ️ Нет пользователей.")
-        logToFile("Список пользователей пуст.")
+        sendMsg(chatID, tr(chatID, "admin.no_users"))
+        logInfo("Список пользователей пуст", "chat_id", chatID, "admin", username, "command", "/list_users")
         return
     }
+    lang := chatLang(chatID)
     var sb strings.Builder
-    sb.WriteString("📋 Пользователи:\n\n")
+    sb.WriteString(T(lang, "admin.users_header"))
     for _, user := range users {
         banStatus := ""
         if user.IsBanned {
-            banStatus = fmt.Sprintf(" (🚫 Забанен до %s, Причина: %s)", user.BanExpires.Format(time.DateTime), user.BanReason)
+            banStatus = T(lang, "admin.user_banned", banUntilFor(chatID, &user), user.BanReason)
         }
         bio := user.Bio
         if bio == "" {
-            bio = "Не указано"
+            bio = T(lang, "admin.no_bio")
+        }
+        strikeStatus := ""
+        if count := countActiveStrikes(user.UserID); count > 0 {
+            strikeStatus = fmt.Sprintf(" | ⚠️ %d", count)
+        }
+        if user.Inactive {
+            banStatus += T(lang, "admin.user_inactive")
         }
-        sb.WriteString(fmt.Sprintf("🆔 %d | 👤 %s | 📛 @%s | 💬 %d | 📝 %s%s\n", user.UserID, user.MinecraftNick, user.Username, user.ChatID, bio, banStatus))
+        sb.WriteString(fmt.Sprintf("🆔 %d | 👤 %s | 📛 @%s | 💬 %d | 📝 %s%s%s\n", user.UserID, user.MinecraftNick+nickBadge(&user), user.Username, user.ChatID, bio, strikeStatus, banStatus))
     }
-    logToFile(fmt.Sprintf("Отправлен список пользователей: %d записей", len(users)))
+    logInfo("Отправлен список пользователей", "chat_id", chatID, "admin", username, "command", "/list_users", "count", len(users))
     sendMsg(chatID, sb.String())
 }
 
 // Удаление пользователя
 func deleteUser(chatID int64, text string, username string) {
     if username != AdminUser1 && username != AdminUser2 {
-        sendMsg(chatID, "❌ У вас нет прав.")
+        sendMsg(chatID, tr(chatID, "no_rights"))
         return
     }
     parts := strings.SplitN(text, " ", 3)
     if len(parts) < 3 {
-        sendMsg(chatID, "❌ Формат: /del_user [ID] [причина]")
+        sendMsg(chatID, tr(chatID, "admin.del_user_format"))
         return
     }
     userID, err := strconv.Atoi(parts[1])
     if err != nil {
-        sendMsg(chatID, "❌ Некорректный ID.")
+        sendMsg(chatID, tr(chatID, "bad_id"))
         return
     }
+    // Языки считаются до удаления: tr берёт userMutex
+    target := getUserByUserID(userID)
+    if target == nil {
+        sendMsg(chatID, tr(chatID, "user_not_found", userID))
+        return
+    }
+    adminText := tr(chatID, "admin.user_deleted", target.Username, userID)
+    userText := tr(target.ChatID, "admin.account_deleted", parts[2])
     userMutex.Lock()
     defer userMutex.Unlock()
     for i, user := range users {
         if user.UserID == userID {
             users = append(users[:i], users[i+1:]...)
             saveUsers()
-            sendMsg(chatID, fmt.Sprintf("✅ @%s (ID: %d) удалён.", user.Username, userID))
-            sendMsg(user.ChatID, fmt.Sprintf("🚫 Аккаунт удалён. Причина: %s", parts[2]))
+            recordAudit("@"+username, "del_user", auditUser(&user), user.MinecraftNick, "причина: "+parts[2])
+            sendMsg(chatID, adminText)
+            sendMsg(user.ChatID, userText)
             return
         }
     }
-    sendMsg(chatID, fmt.Sprintf("❌ Пользователь с ID %d не найден.", userID))
+    sendMsg(chatID, tr(chatID, "user_not_found", userID))
 }
 
 // Разблокировка
 func unbanUserByAdmin(chatID int64, text string, username string) {
     if username != AdminUser1 && username != AdminUser2 {
-        sendMsg(chatID, "❌ У вас нет прав.")
+        sendMsg(chatID, tr(chatID, "no_rights"))
         return
     }
     parts := strings.SplitN(text, " ", 2)
     if len(parts) != 2 {
-        sendMsg(chatID, "❌ Формат: /unban_user [ID]")
+        sendMsg(chatID, tr(chatID, "admin.unban_format"))
         return
     }
     userID, err := strconv.Atoi(parts[1])
     if err != nil {
-        sendMsg(chatID, "❌ Некорректный ID.")
+        sendMsg(chatID, tr(chatID, "bad_id"))
         return
     }
     user := getUserByUserID(userID)
     if user == nil {
-        sendMsg(chatID, fmt.Sprintf("❌ Пользователь с ID %d не найден.", userID))
+        sendMsg(chatID, tr(chatID, "user_not_found", userID))
         return
     }
     if !user.IsBanned {
-        sendMsg(chatID, fmt.Sprintf("❌ @%s (ID: %d) не заблокирован.", user.Username, userID))
+        sendMsg(chatID, tr(chatID, "admin.not_banned", user.Username, userID))
         return
     }
-    unbanUser(user)
-    sendMsg(chatID, fmt.Sprintf("✅ @%s (ID: %d) разблокирован.", user.Username, userID))
-    sendMsg(user.ChatID, "✅ Вы разблокированы.")
+    unbanUser(user, "@"+username)
+    sendMsg(chatID, tr(chatID, "admin.unbanned", user.Username, userID))
+    sendMsg(user.ChatID, tr(user.ChatID, "unbanned"))
 }
 
 // Перезапуск
 func restartBot(chatID int64, username string) {
     if username != AdminUser1 && username != AdminUser2 {
-        sendMsg(chatID, "❌ У вас нет прав.")
+        sendMsg(chatID, tr(chatID, "no_rights"))
         return
     }
-    notifyAllUsers("⚠️ Бот перезагрузится через 10 секунд.")
+    recordAudit("@"+username, "restart", "", "", "")
+    notifyAllUsers("admin.restart_notice")
     go func() {
         time.Sleep(10 * time.Second)
-        saveUsers()
-        saveVacancies()
-        saveResponses()
-        saveCallouts()
-        logFile.Close()
-        statsLogFile.Close()
-        os.Exit(0)
+        stopBot()
     }()
-    sendMsg(chatID, "✅ Перезапуск через 10 секунд.")
+    sendMsg(chatID, tr(chatID, "admin.restarting"))
 }
 
 // Версия
 func showVersion(chatID int64) {
-    sendMsg(chatID, "🤖 CASSMP Bot v1.9\nДля Minecraft-сообщества.")
+    sendMsg(chatID, tr(chatID, "version"))
 }
 // cd и в какой папке находится код "..."
 //
@@ -0,0 +1,187 @@
+package main
+
+import (
+    "fmt"
+    "net/http"
+    "runtime"
+    "sort"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+// Адрес HTTP-сервера метрик, например "127.0.0.1:9100". Пустая строка — сервер выключен.
+const MetricsAddr = ""
+
+// Не больше стольких разных значений метки command, остальные идут в "other"
+const maxCommandLabels = 100
+
+var (
+    updateBuckets   = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+    updateCounts    = make(map[string]uint64)
+    updateHistogram = make([]uint64, len(updateBuckets))
+    updateSum       float64
+    updateTotal     uint64
+    metricsMutex    sync.Mutex
+
+    messagesSent atomic.Uint64
+    sendErrors   atomic.Uint64
+    botReady     atomic.Bool
+
+    // Вакансии по состояниям: считаются в цикле обновлений, HTTP-обработчик только читает
+    vacanciesOpen       atomic.Int64
+    vacanciesAccepted   atomic.Int64
+    vacanciesModeration atomic.Int64
+    vacanciesHold       atomic.Int64
+)
+
+// Пересчёт числа вакансий по состояниям (вызывать в цикле обновлений)
+func refreshVacancyGauges() {
+    open, accepted, onModeration, onHold := 0, 0, 0, 0
+    for _, vac := range vacancies {
+        switch {
+        case vac.OnModeration:
+            onModeration++
+        case vac.OnHold:
+            onHold++
+        case vac.Accepted:
+            accepted++
+        default:
+            open++
+        }
+    }
+    vacanciesOpen.Store(int64(open))
+    vacanciesAccepted.Store(int64(accepted))
+    vacanciesModeration.Store(int64(onModeration))
+    vacanciesHold.Store(int64(onHold))
+}
+
+// Учёт обработанного обновления
+func observeUpdate(command string, duration time.Duration) {
+    if command == "" {
+        command = "text"
+    }
+    seconds := duration.Seconds()
+    metricsMutex.Lock()
+    defer metricsMutex.Unlock()
+    if _, ok := updateCounts[command]; !ok && len(updateCounts) >= maxCommandLabels {
+        command = "other"
+    }
+    updateCounts[command]++
+    for i, bound := range updateBuckets {
+        if seconds <= bound {
+            updateHistogram[i]++
+        }
+    }
+    updateSum += seconds
+    updateTotal++
+}
+
+// Учёт результата отправки сообщения
+func observeSend(err error) {
+    if err != nil {
+        sendErrors.Add(1)
+        return
+    }
+    messagesSent.Add(1)
+}
+
+// Запуск HTTP-сервера метрик
+func startMetricsServer() {
+    if MetricsAddr == "" {
+        return
+    }
+    mux := http.NewServeMux()
+    mux.HandleFunc("/metrics", handleMetrics)
+    mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+        fmt.Fprintln(w, "ok")
+    })
+    mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+        if !botReady.Load() {
+            http.Error(w, "not ready", http.StatusServiceUnavailable)
+            return
+        }
+        fmt.Fprintln(w, "ok")
+    })
+    go func() {
+        logInfo("Сервер метрик запущен", "addr", MetricsAddr)
+        if err := http.ListenAndServe(MetricsAddr, mux); err != nil {
+            logError("Ошибка сервера метрик", "error", err)
+        }
+    }()
+}
+
+// Метрики в текстовом формате Prometheus
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+    var sb strings.Builder
+    writeMetric := func(name string, kind string, help string) {
+        sb.WriteString(fmt.Sprintf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind))
+    }
+
+    metricsMutex.Lock()
+    writeMetric("bot_updates_total", "counter", "Processed updates by command.")
+    commands := make([]string, 0, len(updateCounts))
+    for command := range updateCounts {
+        commands = append(commands, command)
+    }
+    sort.Strings(commands)
+    for _, command := range commands {
+        sb.WriteString(fmt.Sprintf("bot_updates_total{command=%q} %d\n", command, updateCounts[command]))
+    }
+    writeMetric("bot_update_duration_seconds", "histogram", "Update handling latency.")
+    for i, bound := range updateBuckets {
+        sb.WriteString(fmt.Sprintf("bot_update_duration_seconds_bucket{le=\"%g\"} %d\n", bound, updateHistogram[i]))
+    }
+    sb.WriteString(fmt.Sprintf("bot_update_duration_seconds_bucket{le=\"+Inf\"} %d\n", updateTotal))
+    sb.WriteString(fmt.Sprintf("bot_update_duration_seconds_sum %g\n", updateSum))
+    sb.WriteString(fmt.Sprintf("bot_update_duration_seconds_count %d\n", updateTotal))
+    metricsMutex.Unlock()
+
+    writeMetric("bot_messages_sent_total", "counter", "Messages sent successfully.")
+    sb.WriteString(fmt.Sprintf("bot_messages_sent_total %d\n", messagesSent.Load()))
+    writeMetric("bot_send_errors_total", "counter", "Failed Telegram API sends.")
+    sb.WriteString(fmt.Sprintf("bot_send_errors_total %d\n", sendErrors.Load()))
+    writeMetric("bot_send_retries_total", "counter", "Send retries after flood wait or transient errors.")
+    sb.WriteString(fmt.Sprintf("bot_send_retries_total %d\n", sendRetries.Load()))
+    writeMetric("bot_send_dropped_total", "counter", "Messages dropped because the send queue was full.")
+    sb.WriteString(fmt.Sprintf("bot_send_dropped_total %d\n", sendDropped.Load()))
+    writeMetric("bot_send_queue_size", "gauge", "Messages waiting in the send queue by priority.")
+    sb.WriteString(fmt.Sprintf("bot_send_queue_size{priority=\"high\"} %d\n", sendQueueSize(SendPriorityHigh)))
+    sb.WriteString(fmt.Sprintf("bot_send_queue_size{priority=\"low\"} %d\n", sendQueueSize(SendPriorityLow)))
+    writeMetric("bot_broadcast_queue_size", "gauge", "Broadcast messages waiting to be sent.")
+    sb.WriteString(fmt.Sprintf("bot_broadcast_queue_size %d\n", sendQueueSize(SendPriorityLow)))
+
+    userMutex.Lock()
+    userCount, inactiveCount := len(users), 0
+    for _, user := range users {
+        if user.Inactive {
+            inactiveCount++
+        }
+    }
+    userMutex.Unlock()
+    writeMetric("bot_users", "gauge", "Registered users.")
+    sb.WriteString(fmt.Sprintf("bot_users %d\n", userCount))
+    writeMetric("bot_users_inactive", "gauge", "Users who blocked the bot.")
+    sb.WriteString(fmt.Sprintf("bot_users_inactive %d\n", inactiveCount))
+
+    writeMetric("bot_vacancies", "gauge", "Vacancies by state.")
+    sb.WriteString(fmt.Sprintf("bot_vacancies{state=\"open\"} %d\n", vacanciesOpen.Load()))
+    sb.WriteString(fmt.Sprintf("bot_vacancies{state=\"accepted\"} %d\n", vacanciesAccepted.Load()))
+    sb.WriteString(fmt.Sprintf("bot_vacancies{state=\"moderation\"} %d\n", vacanciesModeration.Load()))
+    sb.WriteString(fmt.Sprintf("bot_vacancies{state=\"hold\"} %d\n", vacanciesHold.Load()))
+
+    var memStats runtime.MemStats
+    runtime.ReadMemStats(&memStats)
+    writeMetric("bot_uptime_seconds", "gauge", "Seconds since start.")
+    sb.WriteString(fmt.Sprintf("bot_uptime_seconds %g\n", time.Since(startTime).Seconds()))
+    writeMetric("go_goroutines", "gauge", "Number of goroutines.")
+    sb.WriteString(fmt.Sprintf("go_goroutines %d\n", runtime.NumGoroutine()))
+    writeMetric("go_memstats_heap_alloc_bytes", "gauge", "Heap bytes allocated and in use.")
+    sb.WriteString(fmt.Sprintf("go_memstats_heap_alloc_bytes %d\n", memStats.HeapAlloc))
+    writeMetric("go_memstats_sys_bytes", "gauge", "Bytes obtained from the OS.")
+    sb.WriteString(fmt.Sprintf("go_memstats_sys_bytes %d\n", memStats.Sys))
+
+    w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+    fmt.Fprint(w, sb.String())
+}
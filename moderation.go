@@ -0,0 +1,423 @@
+package main
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Типы и статусы материалов на модерации
+const (
+    ModerationVacancy  = "vacancy"
+    ModerationResponse = "response"
+    ModerationOffer    = "offer"
+    ModerationCallout  = "callout"
+    ModerationNick     = "nick" // OldNick — прежний ник, Text — запрошенный, RefID — UserID
+
+    ModerationPending  = "pending"
+    ModerationApproved = "approved"
+    ModerationRejected = "rejected"
+)
+
+type ModerationItem struct {
+    ID           int
+    Kind         string
+    RefID        int
+    AuthorChatID int64
+    AuthorName   string
+    Status       string
+    Reason       string
+    ReviewedBy   string
+    Comment      string
+    CreatedAt    time.Time
+    ReviewedAt   time.Time
+    Text         string
+    Attachment   string // вложение отклика
+    OldNick      string // ник до смены, только для ModerationNick
+}
+
+// Маркер строк moderation.txt, где все поля экранированы и вложение идёт после текста
+const moderationFormatV2 = "v2|"
+
+var (
+    moderationQueue  []ModerationItem
+    moderationMutex  sync.Mutex
+    nextModerationID = 1
+)
+
+// Загрузка очереди модерации
+func loadModerationQueue() {
+    moderationMutex.Lock()
+    defer moderationMutex.Unlock()
+
+    file, err := os.Open(ModerationFile)
+    if err != nil {
+        return
+    }
+    defer file.Close()
+
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        line := scanner.Text()
+        var parts []string
+        if strings.HasPrefix(line, moderationFormatV2) {
+            // v2: поля экранированы escapeField, новые столбцы дописываются в конец
+            parts = strings.Split(strings.TrimPrefix(line, moderationFormatV2), "|")
+            for i := range parts {
+                parts[i] = unescapeLine(parts[i])
+            }
+        } else {
+            // Старый формат: текст последним и может содержать "|"
+            parts = strings.SplitN(line, "|", 12)
+            if len(parts) == 12 {
+                parts[11] = unescapeLine(parts[11])
+            }
+        }
+        if len(parts) >= 12 {
+            attachment, oldNick := "", ""
+            if len(parts) >= 13 {
+                attachment = parts[12]
+            }
+            if len(parts) >= 14 {
+                oldNick = parts[13]
+            } else if parts[1] == ModerationNick {
+                // Раньше прежний ник хранился в Reason
+                oldNick, parts[6] = parts[6], ""
+            }
+            id, _ := strconv.Atoi(parts[0])
+            refID, _ := strconv.Atoi(parts[2])
+            authorChatID, _ := strconv.ParseInt(parts[3], 10, 64)
+            createdAt, _ := time.Parse(time.RFC3339, parts[9])
+            reviewedAt, _ := time.Parse(time.RFC3339, parts[10])
+            moderationQueue = append(moderationQueue, ModerationItem{
+                ID:           id,
+                Kind:         parts[1],
+                RefID:        refID,
+                AuthorChatID: authorChatID,
+                AuthorName:   parts[4],
+                Status:       parts[5],
+                Reason:       parts[6],
+                ReviewedBy:   parts[7],
+                Comment:      parts[8],
+                CreatedAt:    createdAt,
+                ReviewedAt:   reviewedAt,
+                Attachment:   attachment,
+                Text:         parts[11],
+                OldNick:      oldNick,
+            })
+            if id >= nextModerationID {
+                nextModerationID = id + 1
+            }
+        }
+    }
+}
+
+// Сохранение очереди модерации (вызывать под moderationMutex)
+func saveModerationQueue() {
+    file, err := os.Create(ModerationFile)
+    if err != nil {
+        logError("Ошибка сохранения moderation.txt", "error", err)
+        return
+    }
+    defer file.Close()
+
+    for _, item := range moderationQueue {
+        reviewedAt := ""
+        if !item.ReviewedAt.IsZero() {
+            reviewedAt = item.ReviewedAt.Format(time.RFC3339)
+        }
+        _, err := file.WriteString(fmt.Sprintf("%s%d|%s|%d|%d|%s|%s|%s|%s|%s|%s|%s|%s|%s|%s\n", moderationFormatV2, item.ID, item.Kind, item.RefID, item.AuthorChatID, escapeField(item.AuthorName), item.Status, escapeField(item.Reason), escapeField(item.ReviewedBy), escapeField(item.Comment), item.CreatedAt.Format(time.RFC3339), reviewedAt, escapeField(item.Text), escapeField(item.Attachment), escapeField(item.OldNick)))
+        if err != nil {
+            logError("Ошибка записи элемента модерации", "item_id", item.ID, "error", err)
+        }
+    }
+}
+
+func moderationKindText(lang string, kind string) string {
+    switch kind {
+    case ModerationVacancy, ModerationResponse, ModerationOffer, ModerationCallout, ModerationNick:
+        return T(lang, "mod.kind_"+kind)
+    }
+    return kind
+}
+
+// Постановка материала в очередь и уведомление персонала
+func enqueueModeration(kind string, refID int, authorChatID int64, authorName string, text string, attachment string, reason string) {
+    queueModerationItem(ModerationItem{
+        Kind:         kind,
+        RefID:        refID,
+        AuthorChatID: authorChatID,
+        AuthorName:   authorName,
+        Reason:       reason,
+        Attachment:   attachment,
+        Text:         text,
+    })
+}
+
+// Заявка на смену ника; прежний ник нужен, чтобы при одобрении проверить, что он не изменился
+func enqueueNickRequest(user *User, newNick string) {
+    queueModerationItem(ModerationItem{
+        Kind:         ModerationNick,
+        RefID:        user.UserID,
+        AuthorChatID: user.ChatID,
+        AuthorName:   "@" + user.Username,
+        Text:         newNick,
+        OldNick:      user.MinecraftNick,
+    })
+}
+
+func queueModerationItem(item ModerationItem) {
+    moderationMutex.Lock()
+    item.ID = nextModerationID
+    item.Status = ModerationPending
+    item.CreatedAt = time.Now()
+    nextModerationID++
+    moderationQueue = append(moderationQueue, item)
+    saveModerationQueue()
+    moderationMutex.Unlock()
+
+    for _, admin := range []string{AdminUser1, AdminUser2} {
+        if adminUser := getUserByUsername(admin); adminUser != nil {
+            sendModerationCard(adminUser.ChatID, item)
+        }
+    }
+    logWarn("Материал отправлен на модерацию", "item_id", item.ID, "kind", item.Kind, "ref_id", item.RefID, "chat_id", item.AuthorChatID, "author", item.AuthorName, "match", item.Reason)
+}
+
+func moderationKeyboard(lang string, itemID int) tgbotapi.InlineKeyboardMarkup {
+    return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+        tgbotapi.NewInlineKeyboardButtonData(T(lang, "mod.approve"), fmt.Sprintf("mod:approve:%d", itemID)),
+        tgbotapi.NewInlineKeyboardButtonData(T(lang, "mod.reject"), fmt.Sprintf("mod:reject:%d", itemID)),
+    ))
+}
+
+// Карточка с кнопками; вложение отправляется отдельным сообщением, чтобы карточку
+// можно было отредактировать после решения
+func sendModerationCard(chatID int64, item ModerationItem) {
+    lang := chatLang(chatID)
+    if item.Attachment != "" {
+        sendMsgWithAttachment(chatID, T(lang, "mod.attachment", item.ID), item.Attachment, nil)
+    }
+    sendMsgWithKeyboard(chatID, moderationCard(lang, item), moderationKeyboard(lang, item.ID))
+}
+
+func moderationCard(lang string, item ModerationItem) string {
+    var sb strings.Builder
+    if item.Kind == ModerationNick {
+        sb.WriteString(T(lang, "mod.card_nick", item.ID, moderationKindText(lang, item.Kind), item.AuthorName, item.RefID))
+    } else {
+        ref := ""
+        if item.RefID != 0 {
+            ref = fmt.Sprintf(" #%d", item.RefID)
+        }
+        sb.WriteString(T(lang, "mod.card", item.ID, moderationKindText(lang, item.Kind), ref, item.AuthorName))
+    }
+    if item.Reason != "" {
+        sb.WriteString(T(lang, "mod.card_match", item.Reason))
+    }
+    if item.Kind == ModerationNick {
+        sb.WriteString(fmt.Sprintf("\n%s → %s", item.OldNick, item.Text))
+    } else {
+        sb.WriteString("\n\n" + item.Text)
+    }
+    return sb.String()
+}
+
+// Решение по материалу. Возвращает текст результата для персонала на языке lang.
+func reviewModerationItem(itemID int, approve bool, reviewer string, comment string, lang string) (string, error) {
+    moderationMutex.Lock()
+    var item *ModerationItem
+    for i := range moderationQueue {
+        if moderationQueue[i].ID == itemID {
+            item = &moderationQueue[i]
+            break
+        }
+    }
+    if item == nil {
+        moderationMutex.Unlock()
+        return "", errors.New(T(lang, "mod.not_found", itemID))
+    }
+    if item.Status != ModerationPending {
+        reviewedBy := item.ReviewedBy
+        moderationMutex.Unlock()
+        return "", errors.New(T(lang, "mod.reviewed", itemID, reviewedBy))
+    }
+    item.Status = ModerationRejected
+    if approve {
+        item.Status = ModerationApproved
+    }
+    item.ReviewedBy = reviewer
+    item.Comment = comment
+    item.ReviewedAt = time.Now()
+    reviewed := *item
+    saveModerationQueue()
+    moderationMutex.Unlock()
+
+    if approve {
+        approveModerationItem(reviewed)
+    } else {
+        rejectModerationItem(reviewed)
+    }
+    logInfo("Материал рассмотрен", "item_id", itemID, "kind", reviewed.Kind, "admin", reviewer, "status", reviewed.Status)
+    recordAudit("@"+reviewer, "moderation", fmt.Sprintf("#%d (%s)", itemID, reviewed.Kind), ModerationPending, reviewed.Status)
+
+    verdict := T(lang, "mod.approved")
+    if !approve {
+        verdict = T(lang, "mod.rejected")
+    }
+    return fmt.Sprintf("%s\n\n%s @%s", moderationCard(lang, reviewed), verdict, reviewer), nil
+}
+
+// Публикация одобренного материала
+func approveModerationItem(item ModerationItem) {
+    switch item.Kind {
+    case ModerationVacancy:
+        for i := range vacancies {
+            if vacancies[i].ID == item.RefID {
+                vacancies[i].OnModeration = false
+                vac := vacancies[i]
+                saveVacancies()
+                sendMsg(item.AuthorChatID, tr(item.AuthorChatID, "mod.vac_published", vac.ID))
+                announceVacancy(vac)
+                return
+            }
+        }
+        sendMsg(item.AuthorChatID, tr(item.AuthorChatID, "mod.vac_gone", item.RefID))
+    case ModerationResponse:
+        applyResponse(item.AuthorChatID, item.AuthorName, item.RefID, item.Text, item.Attachment)
+    case ModerationOffer:
+        applyOffer(item.AuthorChatID, item.RefID, item.Text)
+    case ModerationCallout:
+        calloutMutex.Lock()
+        if c := findCallout(item.RefID); c != nil {
+            c.Status = CalloutStatusNew
+            saveCallouts()
+        }
+        calloutMutex.Unlock()
+        sendMsg(item.AuthorChatID, tr(item.AuthorChatID, "mod.callout_ok"))
+    case ModerationNick:
+        applyNickRequest(item)
+    }
+}
+
+// Отклонение материала
+func rejectModerationItem(item ModerationItem) {
+    reason := ""
+    if item.Comment != "" {
+        reason = tr(item.AuthorChatID, "mod.reason", item.Comment)
+    }
+    switch item.Kind {
+    case ModerationVacancy:
+        for i := range vacancies {
+            if vacancies[i].ID == item.RefID {
+                vacancies = append(vacancies[:i], vacancies[i+1:]...)
+                saveVacancies()
+                cancelVacancyJobs(item.RefID)
+                break
+            }
+        }
+        sendMsg(item.AuthorChatID, tr(item.AuthorChatID, "mod.vac_rejected", item.RefID, reason))
+    case ModerationResponse, ModerationOffer:
+        sendMsg(item.AuthorChatID, tr(item.AuthorChatID, "mod."+item.Kind+"_rejected", item.RefID, reason))
+    case ModerationCallout:
+        calloutMutex.Lock()
+        for i := range callouts {
+            if callouts[i].ID == item.RefID {
+                callouts = append(callouts[:i], callouts[i+1:]...)
+                saveCallouts()
+                break
+            }
+        }
+        calloutMutex.Unlock()
+        sendMsg(item.AuthorChatID, tr(item.AuthorChatID, "mod.callout_rejected", reason))
+    case ModerationNick:
+        sendMsg(item.AuthorChatID, tr(item.AuthorChatID, "nick.rejected", item.Text, reason))
+    }
+}
+
+// Кнопки одобрения/отклонения
+func handleModerationCallback(query *tgbotapi.CallbackQuery, args []string) {
+    lang := chatLang(callbackChatID(query))
+    if !isAdmin(query.From.UserName) {
+        answerCallback(query.ID, T(lang, "no_rights"))
+        return
+    }
+    if len(args) != 2 {
+        answerCallback(query.ID, T(lang, "bad_button"))
+        return
+    }
+    itemID, err := strconv.Atoi(args[1])
+    if err != nil {
+        answerCallback(query.ID, T(lang, "bad_button"))
+        return
+    }
+    result, err := reviewModerationItem(itemID, args[0] == "approve", query.From.UserName, "", lang)
+    if err != nil {
+        answerCallback(query.ID, "❌ "+err.Error())
+        return
+    }
+    answerCallback(query.ID, T(lang, "done"))
+    if query.Message != nil {
+        editMsg(query.Message.Chat.ID, query.Message.MessageID, result)
+    }
+}
+
+// Одобрение/отклонение командой
+func processModerationCommand(chatID int64, text string, username string, approve bool) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    parts := strings.SplitN(text, " ", 3)
+    if len(parts) < 2 {
+        sendMsg(chatID, tr(chatID, "mod.format", parts[0]))
+        return
+    }
+    itemID, err := strconv.Atoi(strings.TrimPrefix(parts[1], "#"))
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "bad_id"))
+        return
+    }
+    comment := ""
+    if len(parts) == 3 {
+        comment = strings.TrimSpace(parts[2])
+    }
+    result, err := reviewModerationItem(itemID, approve, username, comment, chatLang(chatID))
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "error", err))
+        return
+    }
+    sendMsg(chatID, result)
+}
+
+// Очередь модерации
+func showModerationQueue(chatID int64, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    moderationMutex.Lock()
+    var pending []ModerationItem
+    for _, item := range moderationQueue {
+        if item.Status == ModerationPending {
+            pending = append(pending, item)
+        }
+    }
+    moderationMutex.Unlock()
+
+    if len(pending) == 0 {
+        sendMsg(chatID, tr(chatID, "mod.empty"))
+        return
+    }
+    sendMsg(chatID, tr(chatID, "mod.pending", len(pending)))
+    for _, item := range pending {
+        sendModerationCard(chatID, item)
+    }
+}
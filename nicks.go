@@ -0,0 +1,134 @@
+package main
+
+import (
+    "regexp"
+    "strings"
+    "time"
+)
+
+// Пользователь может менять ник не чаще раза в NickChangeCooldown
+const NickChangeCooldown = 30 * 24 * time.Hour
+
+// Ник Minecraft: латиница, цифры и _, от 3 до 16 символов
+var minecraftNickPattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,16}$`)
+
+// Ники, которые нельзя занять: под ними легко выдать себя за администрацию.
+// Длинные названия запрещены и в начале или конце ника (AdminVasya, TheOwner),
+// короткие — только целиком, иначе под запрет попадут Modest или Bottle.
+var reservedNicks = []string{
+    "admin", "administrator", "moderator", "mod", "owner", "staff", "support",
+    "server", "console", "system", "bot", "root", "helper", "cassmp",
+}
+
+const reservedNickMinAffix = 5
+
+// Цифры, которыми подменяют буквы: Adm1n, M0derator
+var nickLeetReplacer = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b", "_", "")
+
+// Ник для сравнения с зарезервированными: нижний регистр, без цифр и _ по краям
+// (admin2, _admin_), цифры внутри заменены на похожие буквы
+func normalizeReservedNick(nick string) string {
+    nick = strings.Trim(strings.ToLower(nick), "_0123456789")
+    return nickLeetReplacer.Replace(nick)
+}
+
+func isReservedNick(nick string) bool {
+    normalized := normalizeReservedNick(nick)
+    for _, reserved := range reservedNicks {
+        if normalized == reserved {
+            return true
+        }
+        if len(reserved) >= reservedNickMinAffix && (strings.HasPrefix(normalized, reserved) || strings.HasSuffix(normalized, reserved)) {
+            return true
+        }
+    }
+    return false
+}
+
+// Проверка ника на языке lang: текст ошибки или пустая строка
+func validateNick(lang string, nick string) string {
+    if !minecraftNickPattern.MatchString(nick) {
+        return T(lang, "nick.format")
+    }
+    if isReservedNick(nick) {
+        return T(lang, "nick.reserved")
+    }
+    if hasForbidden, word := containsForbiddenWords(nick); hasForbidden {
+        return T(lang, "nick.forbidden", word)
+    }
+    return ""
+}
+
+// /request_nick НОВЫЙ_НИК — заявка на смену ника, уходит администрации в очередь модерации
+func processRequestNickCommand(chatID int64, text string) {
+    user := getUser(chatID)
+    if user == nil || user.MinecraftNick == "" {
+        sendMsg(chatID, tr(chatID, "register_first"))
+        return
+    }
+    parts := strings.Fields(text)
+    if len(parts) != 2 {
+        sendMsg(chatID, tr(chatID, "nick.request_format"))
+        return
+    }
+    newNick := parts[1]
+    if newNick == user.MinecraftNick {
+        sendMsg(chatID, tr(chatID, "nick.same"))
+        return
+    }
+    if errText := validateNick(chatLang(chatID), newNick); errText != "" {
+        sendMsg(chatID, errText)
+        return
+    }
+    if !strings.EqualFold(newNick, user.MinecraftNick) && isNickTaken(newNick) {
+        sendMsg(chatID, tr(chatID, "nick_taken"))
+        return
+    }
+    if nextChange := user.NickChangedAt.Add(NickChangeCooldown); time.Now().Before(nextChange) {
+        sendMsg(chatID, tr(chatID, "nick.cooldown", formatDurationFor(chatID, NickChangeCooldown), nextChange.Format("02.01.2006")))
+        return
+    }
+    if hasPendingNickRequest(chatID) {
+        sendMsg(chatID, tr(chatID, "nick.pending"))
+        return
+    }
+    enqueueNickRequest(user, newNick)
+    sendMsg(chatID, tr(chatID, "nick.requested", newNick))
+}
+
+func hasPendingNickRequest(chatID int64) bool {
+    moderationMutex.Lock()
+    defer moderationMutex.Unlock()
+    for _, item := range moderationQueue {
+        if item.Kind == ModerationNick && item.Status == ModerationPending && item.AuthorChatID == chatID {
+            return true
+        }
+    }
+    return false
+}
+
+// Одобренная заявка: ник меняется, если за время рассмотрения он не изменился и не был занят
+func applyNickRequest(item ModerationItem) {
+    user := getUserByUserID(item.RefID)
+    if user == nil || user.MinecraftNick != item.OldNick {
+        sendMsg(item.AuthorChatID, tr(item.AuthorChatID, "nick.request_stale"))
+        return
+    }
+    if !strings.EqualFold(item.Text, user.MinecraftNick) && isNickTaken(item.Text) {
+        sendMsg(item.AuthorChatID, tr(item.AuthorChatID, "nick_taken"))
+        return
+    }
+    changeNick(user, item.Text)
+    sendMsg(item.AuthorChatID, tr(item.AuthorChatID, "nick.changed", item.Text))
+}
+
+// Смена ника. Вакансии и отклики ссылаются на UserID, поэтому новый ник
+// появляется в них сам, см. displayName.
+func changeNick(user *User, newNick string) {
+    userMutex.Lock()
+    user.MinecraftNick = newNick
+    user.NickChangedAt = time.Now()
+    resetVerification(user)
+    userMutex.Unlock()
+    saveUsers()
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Лимит команды: Burst сообщений подряд, затем по одному раз в Burst/Period
+type RateLimit struct {
+    Burst  int
+    Period time.Duration
+}
+
+type tokenBucket struct {
+    tokens  float64
+    updated time.Time
+}
+
+var (
+    rateLimits = map[string]RateLimit{
+        "response": {Burst: 5, Period: time.Minute},
+        "support":  {Burst: 3, Period: 5 * time.Minute},
+        "chat":     {Burst: 3, Period: time.Minute},
+        "callout":  {Burst: 3, Period: 5 * time.Minute},
+        "create":   {Burst: 3, Period: 10 * time.Minute},
+        "default":  {Burst: 20, Period: time.Minute},
+    }
+    rateBuckets = make(map[string]*tokenBucket)
+    mutedUntil  = make(map[int64]time.Time)
+    rateLimitMu sync.Mutex
+)
+
+// Загрузка лимитов (формат строки: команда|сообщений|период, например response|5|1m)
+func loadRateLimits() {
+    rateLimitMu.Lock()
+    defer rateLimitMu.Unlock()
+
+    if _, err := os.Stat(RateLimitsFile); os.IsNotExist(err) {
+        file, err := os.Create(RateLimitsFile)
+        if err != nil {
+            logWarn("Не удалось создать rate_limits.txt", "error", err)
+            return
+        }
+        defer file.Close()
+        for _, key := range []string{"response", "support", "chat", "callout", "create", "default"} {
+            limit := rateLimits[key]
+            if _, err := file.WriteString(fmt.Sprintf("%s|%d|%s\n", key, limit.Burst, limit.Period)); err != nil {
+                logWarn("Ошибка записи в rate_limits.txt", "error", err)
+                return
+            }
+        }
+        logInfo("Создан rate_limits.txt с лимитами по умолчанию")
+        return
+    }
+
+    file, err := os.Open(RateLimitsFile)
+    if err != nil {
+        logWarn("Не удалось открыть rate_limits.txt", "error", err)
+        return
+    }
+    defer file.Close()
+
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        parts := strings.SplitN(line, "|", 3)
+        if len(parts) != 3 {
+            logWarn("Некорректная строка в rate_limits.txt", "line", line)
+            continue
+        }
+        burst, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+        if err != nil || burst < 1 {
+            logWarn("Некорректное количество в rate_limits.txt", "line", line)
+            continue
+        }
+        period, err := time.ParseDuration(strings.TrimSpace(parts[2]))
+        if err != nil || period <= 0 {
+            logWarn("Некорректный период в rate_limits.txt", "line", line)
+            continue
+        }
+        rateLimits[strings.TrimSpace(parts[0])] = RateLimit{Burst: burst, Period: period}
+    }
+    logInfo("Загружены лимиты команд", "count", len(rateLimits))
+}
+
+// Ключ лимита для входящего сообщения. Отклики считаются отдельно в
+// submitResponse: туда приходят и "Отклик:", и ответы через кнопку.
+func rateLimitKey(text string) string {
+    switch {
+    case strings.HasPrefix(text, "/support"), strings.HasPrefix(text, "/reply"):
+        return "support"
+    case strings.HasPrefix(text, "/chat"):
+        return "chat"
+    case strings.HasPrefix(text, "/callout") && !strings.HasPrefix(text, "/callouts") && !strings.HasPrefix(text, "/callout_"):
+        return "callout"
+    case text == "/create":
+        return "create"
+    }
+    return "default"
+}
+
+// Проверка лимита. Возвращает false, если сообщение нужно проигнорировать.
+// При превышении лимита пользователь временно заглушается.
+func allowMessage(chatID int64, text string) bool {
+    return allowAction(chatID, rateLimitKey(text))
+}
+
+// Проверка лимита key для действия, не привязанного к тексту сообщения
+func allowAction(chatID int64, key string) bool {
+    now := time.Now()
+    rateLimitMu.Lock()
+    if until, ok := mutedUntil[chatID]; ok {
+        if now.Before(until) {
+            rateLimitMu.Unlock()
+            return false
+        }
+        delete(mutedUntil, chatID)
+    }
+
+    limit, ok := rateLimits[key]
+    if !ok {
+        limit = rateLimits["default"]
+    }
+    bucketKey := fmt.Sprintf("%d:%s", chatID, key)
+    bucket, ok := rateBuckets[bucketKey]
+    if !ok {
+        bucket = &tokenBucket{tokens: float64(limit.Burst), updated: now}
+        rateBuckets[bucketKey] = bucket
+    }
+    bucket.tokens += now.Sub(bucket.updated).Seconds() * float64(limit.Burst) / limit.Period.Seconds()
+    if bucket.tokens > float64(limit.Burst) {
+        bucket.tokens = float64(limit.Burst)
+    }
+    bucket.updated = now
+    if bucket.tokens >= 1 {
+        bucket.tokens--
+        rateLimitMu.Unlock()
+        return true
+    }
+    mutedUntil[chatID] = now.Add(RateLimitMuteDuration)
+    rateLimitMu.Unlock()
+
+    logWarn("Чат заглушён: превышен лимит", "chat_id", chatID, "limit", key, "duration", RateLimitMuteDuration)
+    sendMsg(chatID, tr(chatID, "muted", formatDurationFor(chatID, RateLimitMuteDuration)))
+    return false
+}
+
+// Очистка устаревших счётчиков
+func cleanupRateBuckets() {
+    now := time.Now()
+    rateLimitMu.Lock()
+    defer rateLimitMu.Unlock()
+    for key, bucket := range rateBuckets {
+        if now.Sub(bucket.updated) > time.Hour {
+            delete(rateBuckets, key)
+        }
+    }
+    for chatID, until := range mutedUntil {
+        if now.After(until) {
+            delete(mutedUntil, chatID)
+        }
+    }
+}
+
+// Количество открытых (не принятых) вакансий пользователя
+func countOpenVacancies(chatID int64) int {
+    userID := 0
+    if user := getUser(chatID); user != nil {
+        userID = user.UserID
+    }
+    count := 0
+    for _, vac := range vacancies {
+        if vacancyBelongsTo(vac, userID, chatID) && !vac.Accepted {
+            count++
+        }
+    }
+    return count
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+    "bytes"
+    "encoding/binary"
+    "fmt"
+    "io"
+    "net"
+    "strings"
+    "time"
+)
+
+// Адрес RCON сервера Minecraft, например "127.0.0.1:25575". Пустая строка — проверка ников выключена.
+const (
+    RconAddress  = ""
+    RconPassword = "тут пароль rcon"
+    RconTimeout  = 5 * time.Second
+)
+
+// Типы пакетов протокола Source RCON
+const (
+    rconTypeResponse = 0
+    rconTypeCommand  = 2
+    rconTypeAuth     = 3
+    rconMaxPacket    = 4096 + 10
+)
+
+type rconClient struct {
+    conn   net.Conn
+    nextID int32
+}
+
+// Подключение и авторизация на RCON сервере
+func dialRcon(address string, password string) (*rconClient, error) {
+    conn, err := net.DialTimeout("tcp", address, RconTimeout)
+    if err != nil {
+        return nil, err
+    }
+    client := &rconClient{conn: conn}
+    if err := client.auth(password); err != nil {
+        conn.Close()
+        return nil, err
+    }
+    return client, nil
+}
+
+func (c *rconClient) Close() error {
+    return c.conn.Close()
+}
+
+func (c *rconClient) auth(password string) error {
+    id, err := c.write(rconTypeAuth, password)
+    if err != nil {
+        return err
+    }
+    // Перед ответом на авторизацию некоторые серверы присылают пустой RESPONSE_VALUE
+    for {
+        respID, respType, _, err := c.read()
+        if err != nil {
+            return err
+        }
+        if respType != rconTypeCommand {
+            continue
+        }
+        if respID == -1 {
+            return fmt.Errorf("неверный пароль RCON")
+        }
+        if respID != id {
+            return fmt.Errorf("неожиданный ID ответа RCON: %d", respID)
+        }
+        return nil
+    }
+}
+
+// Выполнение команды сервера и её вывод
+func (c *rconClient) Command(command string) (string, error) {
+    id, err := c.write(rconTypeCommand, command)
+    if err != nil {
+        return "", err
+    }
+    respID, respType, body, err := c.read()
+    if err != nil {
+        return "", err
+    }
+    if respType != rconTypeResponse || respID != id {
+        return "", fmt.Errorf("неожиданный ответ RCON: тип %d, ID %d", respType, respID)
+    }
+    return body, nil
+}
+
+// Пакет: длина, ID, тип, тело и два нулевых байта; числа — int32 little-endian
+func (c *rconClient) write(packetType int32, body string) (int32, error) {
+    c.nextID++
+    var buf bytes.Buffer
+    binary.Write(&buf, binary.LittleEndian, int32(len(body)+10))
+    binary.Write(&buf, binary.LittleEndian, c.nextID)
+    binary.Write(&buf, binary.LittleEndian, packetType)
+    buf.WriteString(body)
+    buf.Write([]byte{0, 0})
+    c.conn.SetDeadline(time.Now().Add(RconTimeout))
+    if _, err := c.conn.Write(buf.Bytes()); err != nil {
+        return 0, err
+    }
+    return c.nextID, nil
+}
+
+func (c *rconClient) read() (int32, int32, string, error) {
+    c.conn.SetDeadline(time.Now().Add(RconTimeout))
+    var length int32
+    if err := binary.Read(c.conn, binary.LittleEndian, &length); err != nil {
+        return 0, 0, "", err
+    }
+    if length < 10 || length > rconMaxPacket {
+        return 0, 0, "", fmt.Errorf("некорректная длина пакета RCON: %d", length)
+    }
+    packet := make([]byte, length)
+    if _, err := io.ReadFull(c.conn, packet); err != nil {
+        return 0, 0, "", err
+    }
+    id := int32(binary.LittleEndian.Uint32(packet[0:4]))
+    packetType := int32(binary.LittleEndian.Uint32(packet[4:8]))
+    body := strings.TrimRight(string(packet[8:]), "\x00")
+    return id, packetType, body, nil
+}
+
+// Одна команда на отдельном подключении
+func rconCommand(command string) (string, error) {
+    client, err := dialRcon(RconAddress, RconPassword)
+    if err != nil {
+        return "", err
+    }
+    defer client.Close()
+    return client.Command(command)
+}
+
+// Игроки онлайн из вывода команды list:
+// "There are 2 of a max of 20 players online: Steve, Alex"
+func rconOnlinePlayers() ([]string, error) {
+    output, err := rconCommand("list")
+    if err != nil {
+        return nil, err
+    }
+    return parseOnlinePlayers(output)
+}
+
+func parseOnlinePlayers(output string) ([]string, error) {
+    _, names, ok := strings.Cut(stripFormatting(output), ":")
+    if !ok {
+        return nil, fmt.Errorf("непонятный ответ list: %s", output)
+    }
+    var players []string
+    for _, name := range strings.Split(names, ",") {
+        if name = strings.TrimSpace(name); name != "" {
+            players = append(players, name)
+        }
+    }
+    return players, nil
+}
+
+// Удаление кодов форматирования Minecraft (§a, §l и т.п.)
+func stripFormatting(text string) string {
+    var sb strings.Builder
+    skip := false
+    for _, r := range text {
+        if skip {
+            skip = false
+            continue
+        }
+        if r == '§' {
+            skip = true
+            continue
+        }
+        sb.WriteRune(r)
+    }
+    return sb.String()
+}
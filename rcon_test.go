@@ -0,0 +1,145 @@
+package main
+
+import (
+    "bytes"
+    "encoding/binary"
+    "io"
+    "net"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+// Поддельный RCON сервер: принимает пароль password и отвечает на команды из replies
+func startFakeRcon(t *testing.T, password string, replies map[string]string) string {
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { listener.Close() })
+    go func() {
+        for {
+            conn, err := listener.Accept()
+            if err != nil {
+                return
+            }
+            go serveFakeRcon(conn, password, replies)
+        }
+    }()
+    return listener.Addr().String()
+}
+
+func serveFakeRcon(conn net.Conn, password string, replies map[string]string) {
+    defer conn.Close()
+    authorized := false
+    for {
+        var length int32
+        if err := binary.Read(conn, binary.LittleEndian, &length); err != nil {
+            return
+        }
+        packet := make([]byte, length)
+        if _, err := io.ReadFull(conn, packet); err != nil {
+            return
+        }
+        id := int32(binary.LittleEndian.Uint32(packet[0:4]))
+        packetType := int32(binary.LittleEndian.Uint32(packet[4:8]))
+        body := strings.TrimRight(string(packet[8:]), "\x00")
+        switch {
+        case packetType == rconTypeAuth:
+            // Как ванильный сервер: пустой RESPONSE_VALUE перед ответом на авторизацию
+            writeFakeRcon(conn, id, rconTypeResponse, "")
+            if body != password {
+                writeFakeRcon(conn, -1, rconTypeCommand, "")
+                return
+            }
+            authorized = true
+            writeFakeRcon(conn, id, rconTypeCommand, "")
+        case packetType == rconTypeCommand && authorized:
+            writeFakeRcon(conn, id, rconTypeResponse, replies[body])
+        default:
+            return
+        }
+    }
+}
+
+func writeFakeRcon(conn net.Conn, id int32, packetType int32, body string) {
+    var buf bytes.Buffer
+    binary.Write(&buf, binary.LittleEndian, int32(len(body)+10))
+    binary.Write(&buf, binary.LittleEndian, id)
+    binary.Write(&buf, binary.LittleEndian, packetType)
+    buf.WriteString(body)
+    buf.Write([]byte{0, 0})
+    conn.Write(buf.Bytes())
+}
+
+func TestRconAuthAndCommand(t *testing.T) {
+    address := startFakeRcon(t, "secret", map[string]string{
+        "list": "There are 2 of a max of 20 players online: Steve, Alex",
+    })
+    client, err := dialRcon(address, "secret")
+    if err != nil {
+        t.Fatalf("dialRcon: %v", err)
+    }
+    defer client.Close()
+
+    output, err := client.Command("list")
+    if err != nil {
+        t.Fatalf("Command: %v", err)
+    }
+    players, err := parseOnlinePlayers(output)
+    if err != nil {
+        t.Fatalf("parseOnlinePlayers: %v", err)
+    }
+    if want := []string{"Steve", "Alex"}; !reflect.DeepEqual(players, want) {
+        t.Errorf("игроки = %q, ожидалось %q", players, want)
+    }
+}
+
+func TestRconAuthWrongPassword(t *testing.T) {
+    address := startFakeRcon(t, "secret", nil)
+    client, err := dialRcon(address, "wrong")
+    if err == nil {
+        client.Close()
+        t.Fatal("авторизация с неверным паролем прошла")
+    }
+    if !strings.Contains(err.Error(), "неверный пароль") {
+        t.Errorf("неожиданная ошибка: %v", err)
+    }
+}
+
+func TestParseOnlinePlayers(t *testing.T) {
+    tests := []struct {
+        output  string
+        players []string
+        wantErr bool
+    }{
+        {"There are 2 of a max of 20 players online: Steve, Alex", []string{"Steve", "Alex"}, false},
+        {"§6There are §c1§6 out of maximum §c20§6 players online.\n§6default§r: §fNotch", []string{"Notch"}, false},
+        {"There are 0 of a max of 20 players online: ", nil, false},
+        {"Unknown command", nil, true},
+    }
+    for _, test := range tests {
+        players, err := parseOnlinePlayers(test.output)
+        if (err != nil) != test.wantErr {
+            t.Errorf("parseOnlinePlayers(%q): ошибка %v", test.output, err)
+            continue
+        }
+        if !reflect.DeepEqual(players, test.players) {
+            t.Errorf("parseOnlinePlayers(%q) = %q, ожидалось %q", test.output, players, test.players)
+        }
+    }
+}
+
+func TestStripFormatting(t *testing.T) {
+    tests := map[string]string{
+        "§aSteve":        "Steve",
+        "§l§cRed§r text": "Red text",
+        "plain":          "plain",
+        "trailing§":      "trailing",
+    }
+    for input, want := range tests {
+        if got := stripFormatting(input); got != want {
+            t.Errorf("stripFormatting(%q) = %q, ожидалось %q", input, got, want)
+        }
+    }
+}
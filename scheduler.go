@@ -0,0 +1,321 @@
+package main
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Виды отложенных задач
+const (
+    JobBanExpiry           = "ban_expiry"           // Target — ChatID пользователя
+    JobVacancyExpiry       = "vacancy_expiry"       // Target — ID вакансии
+    JobVacancyReminder     = "vacancy_reminder"     // Target — ID вакансии
+    JobConversationTimeout = "conversation_timeout" // Target — ChatID диалога
+)
+
+// Отложенная задача. В jobs.txt хранятся только ещё не выполненные задачи.
+type Job struct {
+    ID      int
+    Kind    string
+    Target  int64
+    RunAt   time.Time
+    Payload string
+}
+
+var (
+    jobs        []Job
+    jobMutex    sync.Mutex
+    nextJobID   = 1
+    jobWakeup   = make(chan struct{}, 1)
+    jobHandlers = map[string]func(Job){
+        JobBanExpiry:           runBanExpiryJob,
+        JobVacancyExpiry:       runVacancyExpiryJob,
+        JobVacancyReminder:     runVacancyReminderJob,
+        JobConversationTimeout: runConversationTimeoutJob,
+    }
+)
+
+// Загрузка задач
+func loadJobs() {
+    jobMutex.Lock()
+    defer jobMutex.Unlock()
+
+    file, err := os.Open(JobsFile)
+    if err != nil {
+        return
+    }
+    defer file.Close()
+
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        parts := strings.SplitN(scanner.Text(), "|", 5)
+        if len(parts) == 5 {
+            id, _ := strconv.Atoi(parts[0])
+            target, _ := strconv.ParseInt(parts[2], 10, 64)
+            runAt, _ := time.Parse(time.RFC3339, parts[3])
+            jobs = append(jobs, Job{
+                ID:      id,
+                Kind:    parts[1],
+                Target:  target,
+                RunAt:   runAt,
+                Payload: unescapeLine(parts[4]),
+            })
+            if id >= nextJobID {
+                nextJobID = id + 1
+            }
+        }
+    }
+}
+
+// Сохранение задач (вызывать под jobMutex)
+func saveJobs() {
+    file, err := os.Create(JobsFile)
+    if err != nil {
+        logError("Ошибка сохранения jobs.txt", "error", err)
+        return
+    }
+    defer file.Close()
+
+    for _, job := range jobs {
+        _, err := file.WriteString(fmt.Sprintf("%d|%s|%d|%s|%s\n", job.ID, job.Kind, job.Target, job.RunAt.Format(time.RFC3339), escapeLine(job.Payload)))
+        if err != nil {
+            logError("Ошибка записи задачи", "job_id", job.ID, "error", err)
+        }
+    }
+}
+
+// Постановка задачи. Незавершённая задача того же вида для той же цели заменяется.
+func scheduleJob(kind string, target int64, runAt time.Time, payload string) {
+    jobMutex.Lock()
+    removeJobsLocked(kind, target)
+    jobs = append(jobs, Job{
+        ID:      nextJobID,
+        Kind:    kind,
+        Target:  target,
+        RunAt:   runAt,
+        Payload: payload,
+    })
+    nextJobID++
+    saveJobs()
+    jobMutex.Unlock()
+    wakeScheduler()
+}
+
+// Отмена задач вида kind для цели target
+func cancelJobs(kind string, target int64) {
+    jobMutex.Lock()
+    if removeJobsLocked(kind, target) {
+        saveJobs()
+    }
+    jobMutex.Unlock()
+    wakeScheduler()
+}
+
+func removeJobsLocked(kind string, target int64) bool {
+    removed := false
+    kept := jobs[:0]
+    for _, job := range jobs {
+        if job.Kind == kind && job.Target == target {
+            removed = true
+            continue
+        }
+        kept = append(kept, job)
+    }
+    jobs = kept
+    return removed
+}
+
+func hasJob(kind string, target int64) bool {
+    jobMutex.Lock()
+    defer jobMutex.Unlock()
+    for _, job := range jobs {
+        if job.Kind == kind && job.Target == target {
+            return true
+        }
+    }
+    return false
+}
+
+// Время ближайшего запуска задачи вида kind для цели target
+func jobRunAt(kind string, target int64) (time.Time, bool) {
+    jobMutex.Lock()
+    defer jobMutex.Unlock()
+    for _, job := range jobs {
+        if job.Kind == kind && job.Target == target {
+            return job.RunAt, true
+        }
+    }
+    return time.Time{}, false
+}
+
+func wakeScheduler() {
+    select {
+    case jobWakeup <- struct{}{}:
+    default:
+    }
+}
+
+// Извлечение наступивших задач. Задачи удаляются и сохраняются до запуска,
+// поэтому после перезапуска они не выполнятся повторно.
+func takeDueJobs(now time.Time) ([]Job, time.Time) {
+    jobMutex.Lock()
+    defer jobMutex.Unlock()
+
+    var due []Job
+    var next time.Time
+    kept := jobs[:0]
+    for _, job := range jobs {
+        if !job.RunAt.After(now) {
+            due = append(due, job)
+            continue
+        }
+        if next.IsZero() || job.RunAt.Before(next) {
+            next = job.RunAt
+        }
+        kept = append(kept, job)
+    }
+    jobs = kept
+    if len(due) > 0 {
+        saveJobs()
+    }
+    return due, next
+}
+
+// Запуск планировщика. Горутина только отсчитывает время, сами задачи
+// выполняются в цикле обновлений, где меняются вакансии.
+func startScheduler() {
+    go func() {
+        timer := time.NewTimer(0)
+        for {
+            select {
+            case <-timer.C:
+            case <-jobWakeup:
+            }
+            done := make(chan time.Time, 1)
+            runOnMain(func() { done <- runDueJobs(time.Now()) })
+            var next time.Time
+            select {
+            case next = <-done:
+            case <-mainLoopDone:
+                return
+            }
+            timer.Stop()
+            wait := time.Hour
+            if !next.IsZero() {
+                wait = time.Until(next)
+            }
+            timer.Reset(wait)
+        }
+    }()
+}
+
+// Выполнение наступивших задач, возвращает время следующей
+func runDueJobs(now time.Time) time.Time {
+    due, next := takeDueJobs(now)
+    for _, job := range due {
+        handler, ok := jobHandlers[job.Kind]
+        if !ok {
+            logWarn("Неизвестный вид задачи", "job_id", job.ID, "kind", job.Kind)
+            continue
+        }
+        handler(job)
+    }
+    return next
+}
+
+// Восстановление задач для данных, созданных до появления планировщика
+func ensureScheduledJobs() {
+    for _, vac := range vacancies {
+        if vac.Accepted || vac.OnHold {
+            continue
+        }
+        if !hasJob(JobVacancyExpiry, int64(vac.ID)) {
+            scheduleVacancyJobs(vac)
+        }
+    }
+    userMutex.Lock()
+    var banned []User
+    for _, user := range users {
+        if user.IsBanned && !user.BanExpires.IsZero() {
+            banned = append(banned, user)
+        }
+    }
+    userMutex.Unlock()
+    for _, user := range banned {
+        if !hasJob(JobBanExpiry, user.ChatID) {
+            scheduleJob(JobBanExpiry, user.ChatID, user.BanExpires, "")
+        }
+    }
+}
+
+// Задачи жизненного цикла вакансии: напоминание за сутки и удаление
+func scheduleVacancyJobs(vac Vacancy) {
+    scheduleVacancyJobsAt(vac.ID, vac.CreatedAt.AddDate(0, 0, VacancyExpirationDays))
+}
+
+// То же с явным сроком удаления, например после разморозки вакансии
+func scheduleVacancyJobsAt(vacID int, expiresAt time.Time) {
+    if remindAt := expiresAt.Add(-24 * time.Hour); remindAt.After(time.Now()) {
+        scheduleJob(JobVacancyReminder, int64(vacID), remindAt, "")
+    }
+    scheduleJob(JobVacancyExpiry, int64(vacID), expiresAt, "")
+}
+
+// Отмена задач удалённой или замороженной вакансии
+func cancelVacancyJobs(vacID int) {
+    cancelJobs(JobVacancyExpiry, int64(vacID))
+    cancelJobs(JobVacancyReminder, int64(vacID))
+}
+
+// Снятие истёкшего бана
+func runBanExpiryJob(job Job) {
+    user := getUser(job.Target)
+    if user == nil {
+        return
+    }
+    banMutex.Lock()
+    expired := user.IsBanned && !user.BanExpires.IsZero() && !time.Now().Before(user.BanExpires)
+    banMutex.Unlock()
+    if !expired {
+        return
+    }
+    unbanUser(user, "срок истёк")
+    sendMsg(user.ChatID, tr(user.ChatID, "unbanned"))
+}
+
+// Удаление непринятой вакансии по истечении срока
+func runVacancyExpiryJob(job Job) {
+    for i, vac := range vacancies {
+        if int64(vac.ID) != job.Target {
+            continue
+        }
+        if vac.Accepted {
+            return
+        }
+        vacancies = append(vacancies[:i], vacancies[i+1:]...)
+        saveVacancies()
+        if getUser(vac.ChatID) != nil {
+            sendMsg(vac.ChatID, tr(vac.ChatID, "vac.expired", vac.ID, vac.Content, formatDurationFor(vac.ChatID, VacancyExpirationDays*24*time.Hour)))
+        }
+        logInfo("Удалена старая вакансия", "vacancy_id", vac.ID, "chat_id", vac.ChatID, "user_id", vac.AuthorID, "created_at", vac.CreatedAt)
+        return
+    }
+}
+
+// Напоминание автору о скором удалении вакансии
+func runVacancyReminderJob(job Job) {
+    for _, vac := range vacancies {
+        if int64(vac.ID) != job.Target {
+            continue
+        }
+        if !vac.Accepted && !vac.OnModeration && !vac.OnHold {
+            sendMsg(vac.ChatID, tr(vac.ChatID, "vac.reminder", vac.ID, vac.Content))
+        }
+        return
+    }
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+    "errors"
+    "sync/atomic"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Приоритеты исходящих сообщений: ответы пользователям уходят раньше рассылок
+const (
+    SendPriorityHigh = iota
+    SendPriorityLow
+)
+
+// Ограничения Telegram: около 30 сообщений в секунду на бота и около одного в секунду на чат
+const (
+    GlobalSendRate      = 30
+    PerChatSendBurst    = 3
+    PerChatSendInterval = time.Second
+    MaxSendAttempts     = 5
+    HighSendQueueSize   = 1000
+    LowSendQueueSize    = 20000
+)
+
+var (
+    errSendQueueFull = errors.New("очередь отправки переполнена")
+    errSendCancelled = errors.New("отправка отменена")
+)
+
+type outgoingMessage struct {
+    chatID   int64
+    msg      tgbotapi.Chattable
+    priority int
+    attempt  int
+    callback func(tgbotapi.Message, error)
+    stop     *atomic.Bool // если выставлен, сообщение не отправляется
+}
+
+var (
+    highSendQueue = make(chan outgoingMessage, HighSendQueueSize)
+    lowSendQueue  = make(chan outgoingMessage, LowSendQueueSize)
+    sendPending   atomic.Int64
+    sendDropped   atomic.Uint64
+    sendRetries   atomic.Uint64
+    sendQueued    [2]atomic.Int64 // сообщения, разложенные по очередям чатов
+
+    // Используются только горутиной отправки
+    chatQueues     = make(map[int64]*chatSendQueue)
+    activeChats    []int64 // чаты с сообщениями в порядке обслуживания
+    lastSendAt     time.Time
+    floodWaitUntil time.Time
+)
+
+// Очередь одного чата. Сообщения чата уходят по порядку: повтор после ошибки
+// остаётся в начале очереди, а чат пропускается до retryAt.
+type chatSendQueue struct {
+    items   [2][]outgoingMessage // по приоритетам
+    bucket  tokenBucket
+    retryAt time.Time
+}
+
+// Постановка сообщения в очередь. callback вызывается в цикле обновлений после
+// окончательной доставки или ошибки, может быть nil.
+func enqueueSend(chatID int64, msg tgbotapi.Chattable, priority int, callback func(tgbotapi.Message, error)) {
+    enqueueCancellableSend(chatID, msg, priority, nil, callback)
+}
+
+// То же с возможностью отмены: пока stop выставлен, сообщения не отправляются,
+// а callback получает errSendCancelled
+func enqueueCancellableSend(chatID int64, msg tgbotapi.Chattable, priority int, stop *atomic.Bool, callback func(tgbotapi.Message, error)) {
+    sendPending.Add(1)
+    item := outgoingMessage{chatID: chatID, msg: msg, priority: priority, callback: callback, stop: stop}
+    queue := highSendQueue
+    if priority == SendPriorityLow {
+        queue = lowSendQueue
+    }
+    select {
+    case queue <- item:
+    default:
+        sendPending.Add(-1)
+        sendDropped.Add(1)
+        logWarn("Очередь отправки переполнена, сообщение отброшено", "chat_id", chatID)
+        if callback != nil {
+            callback(tgbotapi.Message{}, errSendQueueFull)
+        }
+    }
+}
+
+// Размер очереди отправки для метрик
+func sendQueueSize(priority int) int {
+    queue := highSendQueue
+    if priority == SendPriorityLow {
+        queue = lowSendQueue
+    }
+    return len(queue) + int(sendQueued[priority].Load())
+}
+
+// Запуск горутины отправки. Чаты, которым ещё рано писать, пропускаются,
+// поэтому один активный чат не задерживает остальных.
+func startSendQueue() {
+    go func() {
+        for {
+            acceptOutgoing(highSendQueue, SendPriorityHigh, HighSendQueueSize)
+            acceptOutgoing(lowSendQueue, SendPriorityLow, LowSendQueueSize)
+            index, priority, wait := nextReadyChat(time.Now())
+            if index < 0 {
+                waitOutgoing(wait)
+                continue
+            }
+            deliverNext(index, priority)
+        }
+    }()
+}
+
+// Перенос новых сообщений из канала в очереди чатов
+func acceptOutgoing(queue chan outgoingMessage, priority int, limit int) {
+    for sendQueued[priority].Load() < int64(limit) {
+        select {
+        case item := <-queue:
+            addOutgoing(item)
+        default:
+            return
+        }
+    }
+}
+
+// Ожидание нового сообщения или времени, когда освободится какой-нибудь чат
+func waitOutgoing(wait time.Duration) {
+    timer := time.NewTimer(wait)
+    defer timer.Stop()
+    high, low := highSendQueue, lowSendQueue
+    if sendQueued[SendPriorityHigh].Load() >= HighSendQueueSize {
+        high = nil
+    }
+    if sendQueued[SendPriorityLow].Load() >= LowSendQueueSize {
+        low = nil
+    }
+    select {
+    case item := <-high:
+        addOutgoing(item)
+    case item := <-low:
+        addOutgoing(item)
+    case <-timer.C:
+    }
+}
+
+func addOutgoing(item outgoingMessage) {
+    queue := chatQueueFor(item.chatID, time.Now())
+    if len(queue.items[SendPriorityHigh])+len(queue.items[SendPriorityLow]) == 0 {
+        activeChats = append(activeChats, item.chatID)
+    }
+    queue.items[item.priority] = append(queue.items[item.priority], item)
+    sendQueued[item.priority].Add(1)
+}
+
+func chatQueueFor(chatID int64, now time.Time) *chatSendQueue {
+    queue, ok := chatQueues[chatID]
+    if ok {
+        return queue
+    }
+    if len(chatQueues) > 10000 {
+        for id, q := range chatQueues {
+            if len(q.items[SendPriorityHigh])+len(q.items[SendPriorityLow]) == 0 && now.Sub(q.bucket.updated) > time.Minute {
+                delete(chatQueues, id)
+            }
+        }
+    }
+    queue = &chatSendQueue{bucket: tokenBucket{tokens: PerChatSendBurst, updated: now}}
+    chatQueues[chatID] = queue
+    return queue
+}
+
+// Первый чат, которому уже можно писать: сначала срочные сообщения, потом
+// рассылки. Если таких нет, index < 0, а wait — время до ближайшего.
+func nextReadyChat(now time.Time) (int, int, time.Duration) {
+    wait := time.Hour
+    for priority := SendPriorityHigh; priority <= SendPriorityLow; priority++ {
+        for i, chatID := range activeChats {
+            queue := chatQueues[chatID]
+            if len(queue.items[priority]) == 0 {
+                continue
+            }
+            readyAt := queue.readyAt(now)
+            if !readyAt.After(now) {
+                return i, priority, 0
+            }
+            if d := readyAt.Sub(now); d < wait {
+                wait = d
+            }
+        }
+    }
+    return -1, 0, wait
+}
+
+// Когда чату можно отправить следующее сообщение
+func (q *chatSendQueue) readyAt(now time.Time) time.Time {
+    refillChatBucket(&q.bucket, now)
+    readyAt := q.retryAt
+    if q.bucket.tokens < 1 {
+        if at := now.Add(time.Duration((1 - q.bucket.tokens) * float64(PerChatSendInterval))); at.After(readyAt) {
+            readyAt = at
+        }
+    }
+    return readyAt
+}
+
+// Отправка первого сообщения чата activeChats[index]. Flood wait (retry_after)
+// останавливает всю отправку, временные ошибки повторяются с экспоненциальной
+// задержкой только для этого чата.
+func deliverNext(index int, priority int) {
+    chatID := activeChats[index]
+    queue := chatQueues[chatID]
+    item := queue.items[priority][0]
+    if item.stop != nil && item.stop.Load() {
+        queue.items[priority] = queue.items[priority][1:]
+        sendQueued[priority].Add(-1)
+        rotateActiveChat(index, queue)
+        sendPending.Add(-1)
+        if item.callback != nil {
+            runOnMain(func() { item.callback(tgbotapi.Message{}, errSendCancelled) })
+        }
+        return
+    }
+
+    waitGlobalSlot()
+    refillChatBucket(&queue.bucket, time.Now())
+    queue.bucket.tokens--
+    sent, err := bot.Send(item.msg)
+    if err != nil {
+        item.attempt++
+        var apiErr *tgbotapi.Error
+        isAPIErr := errors.As(err, &apiErr)
+        retry := false
+        if item.attempt < MaxSendAttempts {
+            if isAPIErr && apiErr.RetryAfter > 0 {
+                floodWaitUntil = time.Now().Add(time.Duration(apiErr.RetryAfter) * time.Second)
+                logWarn("Flood wait", "chat_id", chatID, "retry_after", apiErr.RetryAfter)
+                retry = true
+            } else if !isAPIErr || apiErr.Code >= 500 || apiErr.Code == 429 {
+                backoff := time.Second << (item.attempt - 1)
+                queue.retryAt = time.Now().Add(backoff)
+                logWarn("Ошибка отправки, повтор", "chat_id", chatID, "backoff", backoff, "error", err)
+                retry = true
+            }
+        }
+        if retry {
+            sendRetries.Add(1)
+            queue.items[priority][0] = item
+            rotateActiveChat(index, queue)
+            return
+        }
+    }
+
+    queue.items[priority] = queue.items[priority][1:]
+    sendQueued[priority].Add(-1)
+    rotateActiveChat(index, queue)
+    finishSend(item, sent, err)
+}
+
+// Чат переносится в конец очереди обслуживания или убирается, если сообщений больше нет
+func rotateActiveChat(index int, queue *chatSendQueue) {
+    chatID := activeChats[index]
+    activeChats = append(activeChats[:index], activeChats[index+1:]...)
+    if len(queue.items[SendPriorityHigh])+len(queue.items[SendPriorityLow]) > 0 {
+        activeChats = append(activeChats, chatID)
+    }
+}
+
+func finishSend(item outgoingMessage, sent tgbotapi.Message, err error) {
+    sendPending.Add(-1)
+    observeSend(err)
+    if item.callback != nil {
+        runOnMain(func() { item.callback(sent, err) })
+    } else if err != nil {
+        logError("Ошибка отправки", "chat_id", item.chatID, "error", err)
+    }
+}
+
+// Глобальный лимит бота и flood wait — общие для всех чатов, их пережидаем на месте
+func waitGlobalSlot() {
+    now := time.Now()
+    wait := floodWaitUntil.Sub(now)
+    if d := lastSendAt.Add(time.Second / GlobalSendRate).Sub(now); d > wait {
+        wait = d
+    }
+    if wait > 0 {
+        time.Sleep(wait)
+        now = time.Now()
+    }
+    lastSendAt = now
+}
+
+func refillChatBucket(bucket *tokenBucket, now time.Time) {
+    bucket.tokens += float64(now.Sub(bucket.updated)) / float64(PerChatSendInterval)
+    if bucket.tokens > PerChatSendBurst {
+        bucket.tokens = PerChatSendBurst
+    }
+    bucket.updated = now
+}
+
+// Ожидание отправки всего, что уже в очереди
+func flushSendQueue(deadline time.Time) bool {
+    for sendPending.Load() > 0 {
+        if time.Now().After(deadline) {
+            return false
+        }
+        time.Sleep(100 * time.Millisecond)
+    }
+    return true
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+    "context"
+    "os"
+    "os/signal"
+    "sync"
+    "sync/atomic"
+    "syscall"
+    "time"
+)
+
+// Сколько ждать завершения обработчиков и сохранения данных при остановке
+const ShutdownTimeout = 30 * time.Second
+
+var (
+    stopBot      context.CancelFunc
+    shuttingDown atomic.Bool
+    inFlight     sync.RWMutex // обработчики держат RLock, shutdown ждёт их через Lock
+)
+
+// Остановка по SIGINT/SIGTERM или по stopBot(): бот перестаёт получать обновления,
+// цикл в main завершается и вызывает shutdown.
+func watchShutdownSignals() {
+    var ctx context.Context
+    ctx, stopBot = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    go func() {
+        <-ctx.Done()
+        botReady.Store(false)
+        logInfo("Остановка: прекращаем получение обновлений")
+        bot.StopReceivingUpdates()
+    }()
+}
+
+// Выполнение обработчика, которого дождётся shutdown. Возвращает false, если бот
+// уже останавливается. Вложенные вызовы недопустимы.
+func trackInFlight(fn func()) bool {
+    inFlight.RLock()
+    defer inFlight.RUnlock()
+    if shuttingDown.Load() {
+        return false
+    }
+    fn()
+    return true
+}
+
+// Сохранение всех данных
+func saveAll() {
+    userMutex.Lock()
+    saveUsers()
+    userMutex.Unlock()
+    saveVacancies()
+    saveResponses()
+    calloutMutex.Lock()
+    saveCallouts()
+    calloutMutex.Unlock()
+    ticketMutex.Lock()
+    saveTickets()
+    ticketMutex.Unlock()
+    moderationMutex.Lock()
+    saveModerationQueue()
+    moderationMutex.Unlock()
+    strikeMutex.Lock()
+    saveStrikes()
+    strikeMutex.Unlock()
+    conversationMutex.Lock()
+    saveConversations()
+    conversationMutex.Unlock()
+    jobMutex.Lock()
+    saveJobs()
+    jobMutex.Unlock()
+    historyMutex.Lock()
+    saveBanHistory()
+    saveAppeals()
+    historyMutex.Unlock()
+    chatLangMutex.Lock()
+    saveChatLanguages()
+    chatLangMutex.Unlock()
+}
+
+// Завершение работы: ожидание обработчиков, сохранение данных, отправка
+// оставшихся сообщений, закрытие логов
+func shutdown() {
+    shuttingDown.Store(true)
+    deadline := time.Now().Add(ShutdownTimeout)
+    done := make(chan struct{})
+    go func() {
+        inFlight.Lock()
+        saveAll()
+        if !flushSendQueue(deadline) {
+            logWarn("Не отправлены сообщения из очереди", "count", sendPending.Load())
+        }
+        close(done)
+    }()
+    select {
+    case <-done:
+        logInfo("Данные сохранены")
+    case <-time.After(time.Until(deadline) + time.Second):
+        logWarn("Остановка не завершилась вовремя, выходим принудительно", "timeout", ShutdownTimeout)
+    }
+    logInfo("Бот остановлен")
+    statsLogFile.Close()
+    logWriter.Close()
+    os.Exit(0)
+}
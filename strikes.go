@@ -0,0 +1,346 @@
+package main
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+type Strike struct {
+    ID        int
+    UserID    int
+    Reason    string
+    IssuedBy  string
+    CreatedAt time.Time
+    ExpiresAt time.Time
+}
+
+// Порог эскалации: при Count активных страйках — бан на Duration
+type StrikeRule struct {
+    Count    int
+    Duration time.Duration
+}
+
+var (
+    strikes      []Strike
+    strikeRules  []StrikeRule
+    strikeMutex  sync.Mutex
+    nextStrikeID = 1
+)
+
+// Загрузка страйков
+func loadStrikes() {
+    strikeMutex.Lock()
+    defer strikeMutex.Unlock()
+
+    file, err := os.Open(StrikesFile)
+    if err != nil {
+        return
+    }
+    defer file.Close()
+
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        parts := strings.SplitN(scanner.Text(), "|", 6)
+        if len(parts) == 6 {
+            id, _ := strconv.Atoi(parts[0])
+            userID, _ := strconv.Atoi(parts[1])
+            createdAt, _ := time.Parse(time.RFC3339, parts[3])
+            expiresAt, _ := time.Parse(time.RFC3339, parts[4])
+            strikes = append(strikes, Strike{
+                ID:        id,
+                UserID:    userID,
+                IssuedBy:  parts[2],
+                CreatedAt: createdAt,
+                ExpiresAt: expiresAt,
+                Reason:    unescapeLine(parts[5]),
+            })
+            if id >= nextStrikeID {
+                nextStrikeID = id + 1
+            }
+        }
+    }
+}
+
+// Сохранение страйков (вызывать под strikeMutex)
+func saveStrikes() {
+    file, err := os.Create(StrikesFile)
+    if err != nil {
+        logError("Ошибка сохранения strikes.txt", "error", err)
+        return
+    }
+    defer file.Close()
+
+    for _, s := range strikes {
+        _, err := file.WriteString(fmt.Sprintf("%d|%d|%s|%s|%s|%s\n", s.ID, s.UserID, s.IssuedBy, s.CreatedAt.Format(time.RFC3339), s.ExpiresAt.Format(time.RFC3339), escapeLine(s.Reason)))
+        if err != nil {
+            logError("Ошибка записи страйка", "strike_id", s.ID, "error", err)
+        }
+    }
+}
+
+// Загрузка порогов эскалации (формат строки: количество|длительность, например 3|1h)
+func loadStrikeRules() {
+    strikeMutex.Lock()
+    defer strikeMutex.Unlock()
+
+    if _, err := os.Stat(StrikeRulesFile); os.IsNotExist(err) {
+        strikeRules = []StrikeRule{
+            {Count: 3, Duration: time.Hour},
+            {Count: 5, Duration: 24 * time.Hour},
+            {Count: 7, Duration: 7 * 24 * time.Hour},
+        }
+        file, err := os.Create(StrikeRulesFile)
+        if err != nil {
+            logWarn("Не удалось создать strike_rules.txt", "error", err)
+            return
+        }
+        defer file.Close()
+        for _, rule := range strikeRules {
+            if _, err := file.WriteString(fmt.Sprintf("%d|%s\n", rule.Count, rule.Duration)); err != nil {
+                logWarn("Ошибка записи в strike_rules.txt", "error", err)
+                return
+            }
+        }
+        logInfo("Создан strike_rules.txt с порогами по умолчанию")
+        return
+    }
+
+    file, err := os.Open(StrikeRulesFile)
+    if err != nil {
+        logWarn("Не удалось открыть strike_rules.txt", "error", err)
+        return
+    }
+    defer file.Close()
+
+    strikeRules = nil
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        parts := strings.SplitN(line, "|", 2)
+        if len(parts) != 2 {
+            logWarn("Некорректная строка в strike_rules.txt", "line", line)
+            continue
+        }
+        count, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+        if err != nil || count < 1 {
+            logWarn("Некорректное количество в strike_rules.txt", "line", line)
+            continue
+        }
+        duration, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+        if err != nil || duration <= 0 {
+            logWarn("Некорректная длительность в strike_rules.txt", "line", line)
+            continue
+        }
+        strikeRules = append(strikeRules, StrikeRule{Count: count, Duration: duration})
+    }
+    sort.Slice(strikeRules, func(i, j int) bool { return strikeRules[i].Count < strikeRules[j].Count })
+    logInfo("Загружены пороги страйков", "count", len(strikeRules))
+}
+
+// Активные (не истёкшие) страйки пользователя (вызывать под strikeMutex)
+func activeStrikes(userID int) []Strike {
+    now := time.Now()
+    var active []Strike
+    for _, s := range strikes {
+        if s.UserID == userID && s.ExpiresAt.After(now) {
+            active = append(active, s)
+        }
+    }
+    return active
+}
+
+func countActiveStrikes(userID int) int {
+    strikeMutex.Lock()
+    defer strikeMutex.Unlock()
+    return len(activeStrikes(userID))
+}
+
+// Длительность бана за count активных страйков. После последнего порога
+// каждый новый страйк снова даёт бан максимальной длительности.
+func strikeBanDuration(count int) time.Duration {
+    for i := len(strikeRules) - 1; i >= 0; i-- {
+        rule := strikeRules[i]
+        if count == rule.Count || (i == len(strikeRules)-1 && count > rule.Count) {
+            return rule.Duration
+        }
+    }
+    return 0
+}
+
+// Действующий бан не короче бана за страйки: бессрочный или кончается позже.
+// Такой бан эскалация не заменяет (вызывать под banMutex).
+func outlastsStrikeBan(user *User, duration time.Duration, now time.Time) bool {
+    if !user.IsBanned {
+        return false
+    }
+    return user.BanExpires.IsZero() || !user.BanExpires.Before(now.Add(duration))
+}
+
+// Выдача страйка с автоматической эскалацией
+func issueStrike(target *User, reason string, issuedBy string) {
+    now := time.Now()
+    strikeMutex.Lock()
+    strikes = append(strikes, Strike{
+        ID:        nextStrikeID,
+        UserID:    target.UserID,
+        Reason:    reason,
+        IssuedBy:  issuedBy,
+        CreatedAt: now,
+        ExpiresAt: now.AddDate(0, 0, StrikeExpirationDays),
+    })
+    nextStrikeID++
+    saveStrikes()
+    count := len(activeStrikes(target.UserID))
+    banDuration := strikeBanDuration(count)
+    strikeMutex.Unlock()
+
+    logWarn("Выдан страйк", "chat_id", target.ChatID, "user_id", target.UserID, "username", target.Username, "issued_by", issuedBy, "reason", redact(reason), "active", count)
+    sendMsg(target.ChatID, tr(target.ChatID, "strike.issued", reason, count, formatDurationFor(target.ChatID, StrikeExpirationDays*24*time.Hour)))
+
+    if banDuration > 0 {
+        banMutex.Lock()
+        keepBan := outlastsStrikeBan(target, banDuration, now)
+        banMutex.Unlock()
+        if keepBan {
+            logInfo("Эскалация пропущена: действующий бан длиннее", "chat_id", target.ChatID, "user_id", target.UserID, "until", banUntilText(target), "active", count)
+            return
+        }
+        banReason := fmt.Sprintf("%d предупреждений", count)
+        banUser(target, banDuration, banReason, "страйки")
+        notifyAdmins("strike.auto_ban", target.Username, target.UserID, target.BanExpires.Format(time.DateTime), banReason)
+    }
+}
+
+// Фиксация нарушения фильтра: запись в лог и автоматический страйк
+func reportForbiddenWord(chatID int64, word string, place string) {
+    user := getUser(chatID)
+    if user == nil {
+        logWarn("Запрещённое слово", "chat_id", chatID, "word", word, "place", place)
+        return
+    }
+    logWarn("Запрещённое слово", "chat_id", chatID, "user_id", user.UserID, "username", user.Username, "word", word, "place", place)
+    if isAdmin(user.Username) {
+        return
+    }
+    issueStrike(user, fmt.Sprintf("запрещённое слово '%s' %s", word, place), "фильтр")
+}
+
+// Ручное предупреждение
+func processWarnCommand(chatID int64, text string, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    parts := strings.SplitN(text, " ", 3)
+    if len(parts) != 3 || strings.TrimSpace(parts[2]) == "" {
+        sendMsg(chatID, tr(chatID, "strike.format"))
+        return
+    }
+    targetUserID, err := strconv.Atoi(parts[1])
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "bad_id"))
+        return
+    }
+    targetUser := getUserByUserID(targetUserID)
+    if targetUser == nil {
+        sendMsg(chatID, tr(chatID, "user_not_found", targetUserID))
+        return
+    }
+    if targetUser.ChatID == chatID {
+        sendMsg(chatID, tr(chatID, "strike.self"))
+        return
+    }
+    issueStrike(targetUser, strings.TrimSpace(parts[2]), "@"+username)
+    recordAudit("@"+username, "warn", auditUser(targetUser), "", strings.TrimSpace(parts[2]))
+    sendMsg(chatID, tr(chatID, "strike.warned", targetUser.Username, targetUser.UserID, countActiveStrikes(targetUser.UserID)))
+}
+
+// Снятие ошибочного предупреждения
+func processUnwarnCommand(chatID int64, text string, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    parts := strings.Fields(text)
+    if len(parts) != 2 {
+        sendMsg(chatID, tr(chatID, "strike.unwarn_format"))
+        return
+    }
+    strikeID, err := strconv.Atoi(strings.TrimPrefix(parts[1], "#"))
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "bad_id"))
+        return
+    }
+    strikeMutex.Lock()
+    foundIndex := -1
+    for i, s := range strikes {
+        if s.ID == strikeID {
+            foundIndex = i
+            break
+        }
+    }
+    if foundIndex == -1 {
+        strikeMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "strike.not_found", strikeID))
+        return
+    }
+    removed := strikes[foundIndex]
+    strikes = append(strikes[:foundIndex], strikes[foundIndex+1:]...)
+    saveStrikes()
+    strikeMutex.Unlock()
+    recordAudit("@"+username, "unwarn", fmt.Sprintf("ID %d", removed.UserID), fmt.Sprintf("#%d: %s", removed.ID, removed.Reason), "")
+
+    sendMsg(chatID, tr(chatID, "strike.removed", strikeID, removed.UserID))
+    logInfo("Предупреждение снято", "strike_id", strikeID, "chat_id", chatID, "admin", username, "command", "/unwarn", "user_id", removed.UserID)
+}
+
+// Просмотр предупреждений пользователя
+func showStrikes(chatID int64, text string, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    parts := strings.Fields(text)
+    if len(parts) != 2 {
+        sendMsg(chatID, tr(chatID, "strike.list_format"))
+        return
+    }
+    targetUserID, err := strconv.Atoi(parts[1])
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "bad_id"))
+        return
+    }
+    lang := chatLang(chatID)
+    strikeMutex.Lock()
+    var sb strings.Builder
+    now := time.Now()
+    total := 0
+    for _, s := range strikes {
+        if s.UserID != targetUserID {
+            continue
+        }
+        state := T(lang, "strike.until", s.ExpiresAt.Format(time.DateTime))
+        if !s.ExpiresAt.After(now) {
+            state = T(lang, "strike.expired")
+        }
+        sb.WriteString(fmt.Sprintf("#%d | %s | %s | %s | %s\n", s.ID, s.CreatedAt.Format(time.DateTime), s.IssuedBy, state, s.Reason))
+        total++
+    }
+    active := len(activeStrikes(targetUserID))
+    strikeMutex.Unlock()
+
+    if total == 0 {
+        sendMsg(chatID, tr(chatID, "strike.none", targetUserID))
+        return
+    }
+    sendMsg(chatID, tr(chatID, "strike.list", targetUserID, active, sb.String()))
+}
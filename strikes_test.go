@@ -0,0 +1,25 @@
+package main
+
+import (
+    "testing"
+    "time"
+)
+
+func TestStrikeBanKeepsLongerBan(t *testing.T) {
+    now := time.Now()
+    tests := []struct {
+        name string
+        user User
+        want bool
+    }{
+        {"не забанен", User{}, false},
+        {"бессрочный бан", User{IsBanned: true}, true},
+        {"бан длиннее", User{IsBanned: true, BanExpires: now.Add(48 * time.Hour)}, true},
+        {"бан короче", User{IsBanned: true, BanExpires: now.Add(10 * time.Minute)}, false},
+    }
+    for _, test := range tests {
+        if got := outlastsStrikeBan(&test.user, time.Hour, now); got != test.want {
+            t.Errorf("%s: outlastsStrikeBan = %v, ожидалось %v", test.name, got, test.want)
+        }
+    }
+}
@@ -0,0 +1,610 @@
+package main
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Статусы обращений
+const (
+    TicketStatusOpen     = "open"
+    TicketStatusAnswered = "answered"
+    TicketStatusClosed   = "closed"
+)
+
+type Ticket struct {
+    ID         int
+    UserID     int
+    ChatID     int64
+    Username   string
+    Nick       string
+    Status     string
+    AssignedTo string
+    CreatedAt  time.Time
+    UpdatedAt  time.Time
+    Messages   []TicketMessage
+}
+
+type TicketMessage struct {
+    TicketID  int
+    Author    string
+    FromStaff bool
+    Text      string
+    Timestamp time.Time
+}
+
+var (
+    tickets      []Ticket
+    ticketMutex  sync.Mutex
+    nextTicketID = 1
+)
+
+// Экранирование переводов строк для построчных файлов. Обратная косая черта
+// экранируется тоже, чтобы набранное пользователем "\n" не стало переводом строки.
+var lineEscaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n")
+
+func escapeLine(text string) string {
+    return lineEscaper.Replace(text)
+}
+
+// Экранирование поля, за которым в строке идут другие поля: "|" заменяется на "\!"
+var fieldEscaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "|", "\\!")
+
+func escapeField(text string) string {
+    return fieldEscaper.Replace(text)
+}
+
+// Обратное к escapeLine и escapeField. Неизвестные последовательности остаются
+// как есть: так читаются и строки, записанные до экранирования обратной косой черты.
+func unescapeLine(text string) string {
+    if !strings.Contains(text, "\\") {
+        return text
+    }
+    var sb strings.Builder
+    for i := 0; i < len(text); i++ {
+        if text[i] == '\\' && i+1 < len(text) {
+            switch text[i+1] {
+            case 'n':
+                sb.WriteByte('\n')
+                i++
+                continue
+            case '\\':
+                sb.WriteByte('\\')
+                i++
+                continue
+            case '!':
+                sb.WriteByte('|')
+                i++
+                continue
+            }
+        }
+        sb.WriteByte(text[i])
+    }
+    return sb.String()
+}
+
+// Загрузка обращений
+func loadTickets() {
+    ticketMutex.Lock()
+    defer ticketMutex.Unlock()
+
+    file, err := os.Open(TicketsFile)
+    if err != nil {
+        return
+    }
+    defer file.Close()
+
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        parts := strings.Split(scanner.Text(), "|")
+        if len(parts) >= 9 {
+            id, _ := strconv.Atoi(parts[0])
+            userID, _ := strconv.Atoi(parts[1])
+            chatID, _ := strconv.ParseInt(parts[2], 10, 64)
+            createdAt, _ := time.Parse(time.RFC3339, parts[7])
+            updatedAt, _ := time.Parse(time.RFC3339, parts[8])
+            tickets = append(tickets, Ticket{
+                ID:         id,
+                UserID:     userID,
+                ChatID:     chatID,
+                Username:   parts[3],
+                Nick:       parts[4],
+                Status:     parts[5],
+                AssignedTo: parts[6],
+                CreatedAt:  createdAt,
+                UpdatedAt:  updatedAt,
+            })
+            if id >= nextTicketID {
+                nextTicketID = id + 1
+            }
+        }
+    }
+
+    msgFile, err := os.Open(TicketMessagesFile)
+    if err != nil {
+        return
+    }
+    defer msgFile.Close()
+
+    scanner = bufio.NewScanner(msgFile)
+    for scanner.Scan() {
+        parts := strings.SplitN(scanner.Text(), "|", 5)
+        if len(parts) == 5 {
+            ticketID, _ := strconv.Atoi(parts[0])
+            fromStaff, _ := strconv.ParseBool(parts[2])
+            timestamp, _ := time.Parse(time.RFC3339, parts[3])
+            for i := range tickets {
+                if tickets[i].ID == ticketID {
+                    tickets[i].Messages = append(tickets[i].Messages, TicketMessage{
+                        TicketID:  ticketID,
+                        Author:    parts[1],
+                        FromStaff: fromStaff,
+                        Timestamp: timestamp,
+                        Text:      unescapeLine(parts[4]),
+                    })
+                    break
+                }
+            }
+        }
+    }
+}
+
+// Сохранение обращений (вызывать под ticketMutex)
+func saveTickets() {
+    file, err := os.Create(TicketsFile)
+    if err != nil {
+        logError("Ошибка сохранения tickets.txt", "error", err)
+        return
+    }
+    defer file.Close()
+
+    msgFile, err := os.Create(TicketMessagesFile)
+    if err != nil {
+        logError("Ошибка сохранения ticket_messages.txt", "error", err)
+        return
+    }
+    defer msgFile.Close()
+
+    for _, t := range tickets {
+        _, err := file.WriteString(fmt.Sprintf("%d|%d|%d|%s|%s|%s|%s|%s|%s\n", t.ID, t.UserID, t.ChatID, t.Username, t.Nick, t.Status, t.AssignedTo, t.CreatedAt.Format(time.RFC3339), t.UpdatedAt.Format(time.RFC3339)))
+        if err != nil {
+            logError("Ошибка записи обращения", "ticket_id", t.ID, "error", err)
+        }
+        for _, m := range t.Messages {
+            _, err := msgFile.WriteString(fmt.Sprintf("%d|%s|%t|%s|%s\n", m.TicketID, m.Author, m.FromStaff, m.Timestamp.Format(time.RFC3339), escapeLine(m.Text)))
+            if err != nil {
+                logError("Ошибка записи сообщения обращения", "ticket_id", m.TicketID, "error", err)
+            }
+        }
+    }
+}
+
+// Поиск обращения (вызывать под ticketMutex)
+func findTicket(id int) *Ticket {
+    for i := range tickets {
+        if tickets[i].ID == id {
+            return &tickets[i]
+        }
+    }
+    return nil
+}
+
+func ticketStatusText(lang string, status string) string {
+    switch status {
+    case TicketStatusOpen, TicketStatusAnswered, TicketStatusClosed:
+        return T(lang, "ticket.status_"+status)
+    }
+    return status
+}
+
+// Уведомление персонала: назначенному админу или всем
+func notifyTicketStaff(t *Ticket, key string, args ...interface{}) {
+    if t.AssignedTo != "" {
+        if admin := getUserByUsername(t.AssignedTo); admin != nil {
+            sendMsg(admin.ChatID, tr(admin.ChatID, key, args...))
+            return
+        }
+    }
+    notifyAdmins(key, args...)
+}
+
+// Техподдержка: новое обращение или дополнение к существующему
+func processSupportCommand(chatID int64, text string, username string) {
+    user := getUser(chatID)
+    if user == nil {
+        sendMsg(chatID, tr(chatID, "register_first"))
+        return
+    }
+    parts := strings.SplitN(text, " ", 2)
+    if len(parts) != 2 {
+        sendMsg(chatID, tr(chatID, "ticket.format"))
+        return
+    }
+    supportText := strings.TrimSpace(parts[1])
+    if supportText == "" {
+        sendMsg(chatID, tr(chatID, "empty_message"))
+        return
+    }
+
+    ticketID := 0
+    if strings.HasPrefix(supportText, "#") {
+        idParts := strings.SplitN(supportText, " ", 2)
+        id, err := strconv.Atoi(strings.TrimPrefix(idParts[0], "#"))
+        if err != nil {
+            sendMsg(chatID, tr(chatID, "ticket.bad_id"))
+            return
+        }
+        if len(idParts) != 2 || strings.TrimSpace(idParts[1]) == "" {
+            sendMsg(chatID, tr(chatID, "empty_message"))
+            return
+        }
+        ticketID = id
+        supportText = strings.TrimSpace(idParts[1])
+    }
+
+    if hasForbidden, word := containsForbiddenWords(supportText); hasForbidden {
+        sendMsg(chatID, tr(chatID, "forbidden_word", word))
+        reportForbiddenWord(chatID, word, "в техподдержке")
+        return
+    }
+
+    now := time.Now()
+    ticketMutex.Lock()
+    var t *Ticket
+    if ticketID != 0 {
+        t = findTicket(ticketID)
+        if t == nil || t.UserID != user.UserID {
+            ticketMutex.Unlock()
+            sendMsg(chatID, tr(chatID, "ticket.not_found", ticketID))
+            return
+        }
+        if t.Status == TicketStatusClosed {
+            ticketMutex.Unlock()
+            sendMsg(chatID, tr(chatID, "ticket.closed_new", ticketID))
+            return
+        }
+    } else {
+        tickets = append(tickets, Ticket{
+            ID:        nextTicketID,
+            UserID:    user.UserID,
+            ChatID:    chatID,
+            Username:  user.Username,
+            Nick:      user.MinecraftNick,
+            CreatedAt: now,
+        })
+        nextTicketID++
+        t = &tickets[len(tickets)-1]
+    }
+    t.Status = TicketStatusOpen
+    t.UpdatedAt = now
+    t.Messages = append(t.Messages, TicketMessage{
+        TicketID:  t.ID,
+        Author:    user.Username,
+        Text:      supportText,
+        Timestamp: now,
+    })
+    saveTickets()
+    ticket := *t
+    ticketMutex.Unlock()
+
+    if ticketID != 0 {
+        notifyTicketStaff(&ticket, "ticket.staff_followup", ticket.ID, user.Username, user.UserID, user.MinecraftNick, supportText, ticket.ID)
+        sendMsg(chatID, tr(chatID, "ticket.added", ticket.ID))
+    } else {
+        notifyTicketStaff(&ticket, "ticket.staff_new", ticket.ID, user.Username, user.UserID, user.MinecraftNick, supportText, ticket.ID, ticket.ID)
+        sendMsg(chatID, tr(chatID, "ticket.created", ticket.ID, ticket.ID))
+    }
+    logInfo("Сообщение в техподдержку", "ticket_id", ticket.ID, "chat_id", chatID, "user_id", user.UserID, "username", user.Username, "command", "/support", "text", redact(supportText))
+}
+
+// Ответ техподдержки на обращение
+func processReplyCommand(chatID int64, text string, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    parts := strings.SplitN(text, " ", 3)
+    if len(parts) != 3 {
+        sendMsg(chatID, tr(chatID, "ticket.reply_format"))
+        return
+    }
+    ticketID, err := strconv.Atoi(strings.TrimPrefix(parts[1], "#"))
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "bad_id"))
+        return
+    }
+    replyText := strings.TrimSpace(parts[2])
+    if replyText == "" {
+        sendMsg(chatID, tr(chatID, "empty_message"))
+        return
+    }
+
+    now := time.Now()
+    ticketMutex.Lock()
+    t := findTicket(ticketID)
+    if t == nil {
+        ticketMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "ticket.not_found", ticketID))
+        return
+    }
+    if t.Status == TicketStatusClosed {
+        ticketMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "ticket.closed", ticketID))
+        return
+    }
+    if t.AssignedTo == "" {
+        t.AssignedTo = username
+    }
+    t.Status = TicketStatusAnswered
+    t.UpdatedAt = now
+    t.Messages = append(t.Messages, TicketMessage{
+        TicketID:  t.ID,
+        Author:    username,
+        FromStaff: true,
+        Text:      replyText,
+        Timestamp: now,
+    })
+    saveTickets()
+    ticket := *t
+    ticketMutex.Unlock()
+
+    sendMsg(ticket.ChatID, tr(ticket.ChatID, "ticket.reply", ticket.ID, replyText, ticket.ID))
+    sendMsg(chatID, tr(chatID, "ticket.replied", ticket.ID, ticket.Username, ticket.UserID))
+    logInfo("Ответ техподдержки", "ticket_id", ticket.ID, "chat_id", chatID, "admin", username, "command", "/reply", "user_id", ticket.UserID, "text", redact(replyText))
+}
+
+// Список обращений для персонала
+func listTickets(chatID int64, text string, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    filter := "active"
+    parts := strings.Fields(text)
+    if len(parts) > 1 {
+        filter = strings.ToLower(parts[1])
+    }
+    switch filter {
+    case "active", "all", "mine", "unassigned", TicketStatusOpen, TicketStatusAnswered, TicketStatusClosed:
+    default:
+        sendMsg(chatID, tr(chatID, "ticket.list_format"))
+        return
+    }
+
+    lang := chatLang(chatID)
+    ticketMutex.Lock()
+    var sb strings.Builder
+    count := 0
+    for _, t := range tickets {
+        switch filter {
+        case "active":
+            if t.Status == TicketStatusClosed {
+                continue
+            }
+        case "mine":
+            if t.AssignedTo != username || t.Status == TicketStatusClosed {
+                continue
+            }
+        case "unassigned":
+            if t.AssignedTo != "" || t.Status == TicketStatusClosed {
+                continue
+            }
+        case "all":
+        default:
+            if t.Status != filter {
+                continue
+            }
+        }
+        assigned := T(lang, "ticket.unassigned")
+        if t.AssignedTo != "" {
+            assigned = "@" + t.AssignedTo
+        }
+        sb.WriteString(fmt.Sprintf("#%d | %s | @%s (ID: %d) | %s | %s | %s\n", t.ID, ticketStatusText(lang, t.Status), t.Username, t.UserID, assigned, t.UpdatedAt.Format(time.DateTime), ticketPreview(t)))
+        count++
+    }
+    ticketMutex.Unlock()
+
+    if count == 0 {
+        sendMsg(chatID, tr(chatID, "ticket.none"))
+        return
+    }
+    sendMsg(chatID, tr(chatID, "ticket.list", filter, count, sb.String()))
+}
+
+// Первая строка обращения для списков
+func ticketPreview(t Ticket) string {
+    if len(t.Messages) == 0 {
+        return ""
+    }
+    preview := []rune(strings.ReplaceAll(t.Messages[0].Text, "\n", " "))
+    if len(preview) > 40 {
+        return string(preview[:40]) + "…"
+    }
+    return string(preview)
+}
+
+// Просмотр переписки по обращению
+func showTicket(chatID int64, text string, username string) {
+    parts := strings.Fields(text)
+    if len(parts) != 2 {
+        sendMsg(chatID, tr(chatID, "ticket.show_format"))
+        return
+    }
+    ticketID, err := strconv.Atoi(strings.TrimPrefix(parts[1], "#"))
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "bad_id"))
+        return
+    }
+    staff := isAdmin(username)
+    lang := chatLang(chatID)
+
+    ticketMutex.Lock()
+    t := findTicket(ticketID)
+    if t == nil || (!staff && t.ChatID != chatID) {
+        ticketMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "ticket.not_found", ticketID))
+        return
+    }
+    var sb strings.Builder
+    sb.WriteString(T(lang, "ticket.header", t.ID, ticketStatusText(lang, t.Status), t.Username, t.UserID, t.Nick, t.CreatedAt.Format(time.DateTime)))
+    if staff {
+        assigned := T(lang, "ticket.unassigned")
+        if t.AssignedTo != "" {
+            assigned = "@" + t.AssignedTo
+        }
+        sb.WriteString(T(lang, "ticket.assignee", assigned))
+    }
+    sb.WriteString("\n")
+    for _, m := range t.Messages {
+        author := T(lang, "ticket.author_you")
+        if m.FromStaff {
+            author = T(lang, "ticket.author_support")
+            if staff {
+                author = "🛠 @" + m.Author
+            }
+        } else if staff {
+            author = "👤 @" + m.Author
+        }
+        sb.WriteString(fmt.Sprintf("[%s] %s:\n%s\n\n", m.Timestamp.Format(time.DateTime), author, m.Text))
+    }
+    ticketMutex.Unlock()
+    sendMsg(chatID, sb.String())
+}
+
+// Обращения пользователя
+func showMyTickets(chatID int64) {
+    user := getUser(chatID)
+    if user == nil {
+        sendMsg(chatID, tr(chatID, "not_registered"))
+        return
+    }
+    lang := chatLang(chatID)
+    ticketMutex.Lock()
+    var sb strings.Builder
+    count := 0
+    for _, t := range tickets {
+        if t.UserID != user.UserID {
+            continue
+        }
+        sb.WriteString(fmt.Sprintf("#%d | %s | %s | %s\n", t.ID, ticketStatusText(lang, t.Status), t.UpdatedAt.Format(time.DateTime), ticketPreview(t)))
+        count++
+    }
+    ticketMutex.Unlock()
+
+    if count == 0 {
+        sendMsg(chatID, tr(chatID, "ticket.mine_none"))
+        return
+    }
+    sendMsg(chatID, tr(chatID, "ticket.mine", sb.String()))
+}
+
+// Взять обращение в работу
+func processClaimTicketCommand(chatID int64, text string, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    parts := strings.Fields(text)
+    if len(parts) != 2 {
+        sendMsg(chatID, tr(chatID, "ticket.claim_format"))
+        return
+    }
+    assignTicket(chatID, parts[1], username, username)
+}
+
+// Назначить обращение админу
+func processAssignTicketCommand(chatID int64, text string, username string) {
+    if !isAdmin(username) {
+        sendMsg(chatID, tr(chatID, "no_rights"))
+        return
+    }
+    parts := strings.Fields(text)
+    if len(parts) != 3 {
+        sendMsg(chatID, tr(chatID, "ticket.assign_format"))
+        return
+    }
+    assignee := strings.TrimPrefix(parts[2], "@")
+    if !isAdmin(assignee) {
+        sendMsg(chatID, tr(chatID, "ticket.not_admin", assignee))
+        return
+    }
+    assignTicket(chatID, parts[1], assignee, username)
+}
+
+func assignTicket(chatID int64, idStr string, assignee string, actor string) {
+    ticketID, err := strconv.Atoi(strings.TrimPrefix(idStr, "#"))
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "bad_id"))
+        return
+    }
+    ticketMutex.Lock()
+    t := findTicket(ticketID)
+    if t == nil {
+        ticketMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "ticket.not_found", ticketID))
+        return
+    }
+    if t.Status == TicketStatusClosed {
+        ticketMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "ticket.closed", ticketID))
+        return
+    }
+    t.AssignedTo = assignee
+    t.UpdatedAt = time.Now()
+    saveTickets()
+    ticketMutex.Unlock()
+
+    sendMsg(chatID, tr(chatID, "ticket.assigned", ticketID, assignee))
+    if assignee != actor {
+        if admin := getUserByUsername(assignee); admin != nil {
+            sendMsg(admin.ChatID, tr(admin.ChatID, "ticket.assigned_note", actor, ticketID, ticketID))
+        }
+    }
+    logInfo("Обращение назначено", "ticket_id", ticketID, "chat_id", chatID, "admin", actor, "assignee", assignee)
+}
+
+// Закрытие обращения
+func processCloseTicketCommand(chatID int64, text string, username string) {
+    parts := strings.Fields(text)
+    if len(parts) != 2 {
+        sendMsg(chatID, tr(chatID, "ticket.close_format"))
+        return
+    }
+    ticketID, err := strconv.Atoi(strings.TrimPrefix(parts[1], "#"))
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "bad_id"))
+        return
+    }
+    staff := isAdmin(username)
+
+    ticketMutex.Lock()
+    t := findTicket(ticketID)
+    if t == nil || (!staff && t.ChatID != chatID) {
+        ticketMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "ticket.not_found", ticketID))
+        return
+    }
+    if t.Status == TicketStatusClosed {
+        ticketMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "ticket.already_closed", ticketID))
+        return
+    }
+    t.Status = TicketStatusClosed
+    t.UpdatedAt = time.Now()
+    saveTickets()
+    ticket := *t
+    ticketMutex.Unlock()
+
+    sendMsg(chatID, tr(chatID, "ticket.closed_done", ticketID))
+    if staff && ticket.ChatID != chatID {
+        sendMsg(ticket.ChatID, tr(ticket.ChatID, "ticket.closed_by_staff", ticketID))
+    } else {
+        notifyTicketStaff(&ticket, "ticket.closed_by_user", ticket.Username, ticketID)
+    }
+    logInfo("Обращение закрыто", "ticket_id", ticketID, "chat_id", chatID, "username", username, "command", "/close_ticket", "staff", staff)
+}
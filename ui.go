@@ -0,0 +1,345 @@
+package main
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Кнопки главного меню на всех языках и соответствующие им команды
+var menuCommands = make(map[string]string)
+
+var menuButtons = []struct {
+    key     string
+    command string
+}{
+    {"menu.list", "/list"},
+    {"menu.create", "/create"},
+    {"menu.mine", "/my_vacancies"},
+    {"menu.profile", "/profile"},
+    {"menu.help", "/help"},
+}
+
+func init() {
+    for lang := range catalog {
+        for _, button := range menuButtons {
+            menuCommands[T(lang, button.key)] = button.command
+        }
+    }
+}
+
+func mainMenuKeyboard(lang string) tgbotapi.ReplyKeyboardMarkup {
+    return tgbotapi.NewReplyKeyboard(
+        tgbotapi.NewKeyboardButtonRow(
+            tgbotapi.NewKeyboardButton(T(lang, "menu.list")),
+            tgbotapi.NewKeyboardButton(T(lang, "menu.create")),
+        ),
+        tgbotapi.NewKeyboardButtonRow(
+            tgbotapi.NewKeyboardButton(T(lang, "menu.mine")),
+            tgbotapi.NewKeyboardButton(T(lang, "menu.profile")),
+            tgbotapi.NewKeyboardButton(T(lang, "menu.help")),
+        ),
+    )
+}
+
+// Полная карточка вакансии
+func vacancyCardText(lang string, vac Vacancy) string {
+    status := T(lang, "vac.open")
+    switch {
+    case vac.OnModeration:
+        status = T(lang, "vac.moderation")
+    case vac.OnHold:
+        status = T(lang, "vac.hold")
+    case vac.Accepted:
+        status = T(lang, "vac.accepted_by", vacancyAcceptorName(vac))
+    }
+    paymentInfo := vac.PaymentInfo
+    if paymentInfo == "" {
+        paymentInfo = T(lang, "vac.not_set")
+    }
+
+    var sb strings.Builder
+    sb.WriteString(T(lang, "vac.card", vac.ID, vac.Content, vac.Price, paymentInfo))
+    author := vac.Author
+    if user := getUserByUserID(vac.AuthorID); user != nil {
+        author = fmt.Sprintf("%s%s (ID: %d)", user.MinecraftNick, nickBadge(user), user.UserID)
+    }
+    total, accepted := authorVacancyStats(vac.AuthorID, vac.ChatID)
+    sb.WriteString(T(lang, "vac.card_author", author, accepted, total))
+    sb.WriteString(T(lang, "vac.card_status", status))
+    sb.WriteString(T(lang, "vac.card_created", vac.CreatedAt.Format("02.01.2006 15:04")))
+    if expiresAt, ok := jobRunAt(JobVacancyExpiry, int64(vac.ID)); ok && !vac.Accepted {
+        sb.WriteString(T(lang, "vac.card_expires", expiresAt.Format("02.01.2006 15:04")))
+    }
+    sb.WriteString(T(lang, "vac.card_offers", countVacancyResponses(vac.ID)))
+    if kind, _, ok := parseAttachment(vac.Attachment); ok {
+        if kind == AttachmentPhoto {
+            sb.WriteString(T(lang, "vac.card_photo"))
+        } else {
+            sb.WriteString(T(lang, "vac.card_document"))
+        }
+    }
+    if vac.Proof != "" {
+        sb.WriteString(T(lang, "vac.card_proof"))
+    }
+    return sb.String()
+}
+
+// Статистика автора: сколько его опубликованных вакансий принято
+func authorVacancyStats(authorID int, chatID int64) (int, int) {
+    total, accepted := 0, 0
+    for _, vac := range vacancies {
+        if !vacancyBelongsTo(vac, authorID, chatID) || vac.OnModeration {
+            continue
+        }
+        total++
+        if vac.Accepted {
+            accepted++
+        }
+    }
+    return total, accepted
+}
+
+func countVacancyResponses(vacID int) int {
+    count := 0
+    for _, resp := range responses {
+        if resp.VacancyID == vacID {
+            count++
+        }
+    }
+    return count
+}
+
+// Команда /vacancy ID (или /vacancy_ID из списка)
+func processVacancyCommand(chatID int64, text string) {
+    command, arg, _ := strings.Cut(text, " ")
+    // /vacancy_5, /vacancy_5@BotName или /vacancy@BotName 5
+    if id, _, _ := strings.Cut(strings.TrimPrefix(command, "/vacancy"), "@"); id != "" {
+        arg = id
+    }
+    arg = strings.TrimLeft(strings.TrimSpace(arg), "_")
+    vacID, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+    if err != nil {
+        sendMsg(chatID, tr(chatID, "vac.format"))
+        return
+    }
+    showVacancy(chatID, vacID)
+}
+
+func showVacancy(chatID int64, vacID int) {
+    vacancyIndex := findVacancyIndex(vacID)
+    if vacancyIndex == -1 {
+        sendMsg(chatID, tr(chatID, "vac.not_found", vacID))
+        return
+    }
+    vac := vacancies[vacancyIndex]
+    lang := chatLang(chatID)
+    sendMsgWithAttachment(chatID, vacancyCardText(lang, vac), vac.Attachment, vacancyKeyboard(lang, vac))
+}
+
+// Поле в строке списка: одной строкой и не длиннее limit символов, чтобы
+// страница из 10 вакансий гарантированно помещалась в одно сообщение
+func summaryField(text string, limit int) string {
+    runes := []rune(strings.Join(strings.Fields(text), " "))
+    if len(runes) > limit {
+        return string(runes[:limit]) + "…"
+    }
+    return string(runes)
+}
+
+// Краткая строка вакансии для списка
+func vacancySummary(lang string, vac Vacancy) string {
+    status := "🟢"
+    if vac.Accepted {
+        status = "✅"
+    }
+    attachmentMark := ""
+    if vac.Attachment != "" {
+        attachmentMark = " 📎"
+    }
+    return fmt.Sprintf("%s #%d%s %s — %s (%s)\n", status, vac.ID, attachmentMark,
+        summaryField(vac.Content, 40), summaryField(vac.Price, 20), summaryField(vacancyAuthorName(vac), 20)) + T(lang, "vac.summary_more", vac.ID)
+}
+
+// Кнопки карточки: vac:действие:ID
+func vacancyKeyboard(lang string, vac Vacancy) tgbotapi.InlineKeyboardMarkup {
+    id := strconv.Itoa(vac.ID)
+    var rows [][]tgbotapi.InlineKeyboardButton
+    if !vac.Accepted {
+        rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+            tgbotapi.NewInlineKeyboardButtonData(T(lang, "vac.btn_accept"), "vac:accept:"+id),
+            tgbotapi.NewInlineKeyboardButtonData(T(lang, "vac.btn_respond"), "vac:respond:"+id),
+        ))
+    }
+    rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+        tgbotapi.NewInlineKeyboardButtonData(T(lang, "vac.btn_author"), "vac:author:"+id),
+        tgbotapi.NewInlineKeyboardButtonData(T(lang, "vac.btn_report"), "vac:report:"+id),
+    ))
+    return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// Страница списка вакансий с кнопками карточек и переключения страниц
+func vacanciesListView(lang string, page int) (string, *tgbotapi.InlineKeyboardMarkup) {
+    const itemsPerPage = 10
+    var visible []Vacancy
+    for _, vac := range vacancies {
+        if !vac.OnModeration && !vac.OnHold {
+            visible = append(visible, vac)
+        }
+    }
+    if len(visible) == 0 {
+        return T(lang, "vac.none"), nil
+    }
+    pages := (len(visible) + itemsPerPage - 1) / itemsPerPage
+    if page > pages {
+        return T(lang, "vac.no_page", page), nil
+    }
+    startIndex := (page - 1) * itemsPerPage
+    endIndex := startIndex + itemsPerPage
+    if endIndex > len(visible) {
+        endIndex = len(visible)
+    }
+
+    var result strings.Builder
+    result.WriteString(T(lang, "vac.list_header", page, pages))
+    var rows [][]tgbotapi.InlineKeyboardButton
+    var row []tgbotapi.InlineKeyboardButton
+    for _, vac := range visible[startIndex:endIndex] {
+        result.WriteString(vacancySummary(lang, vac) + "\n")
+        row = append(row, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("#%d", vac.ID), fmt.Sprintf("vac:show:%d", vac.ID)))
+        if len(row) == 5 {
+            rows = append(rows, row)
+            row = nil
+        }
+    }
+    if len(row) > 0 {
+        rows = append(rows, row)
+    }
+    var nav []tgbotapi.InlineKeyboardButton
+    if page > 1 {
+        nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(T(lang, "vac.prev"), fmt.Sprintf("list:%d", page-1)))
+    }
+    if page < pages {
+        nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(T(lang, "vac.next"), fmt.Sprintf("list:%d", page+1)))
+    }
+    if len(nav) > 0 {
+        rows = append(rows, nav)
+    }
+    keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+    return result.String(), &keyboard
+}
+
+// Переключение страницы списка с редактированием сообщения
+func handleListCallback(query *tgbotapi.CallbackQuery, args []string) {
+    chatID := callbackChatID(query)
+    if len(args) != 1 || query.Message == nil {
+        answerCallback(query.ID, tr(chatID, "bad_button"))
+        return
+    }
+    page, err := strconv.Atoi(args[0])
+    if err != nil || page < 1 {
+        answerCallback(query.ID, tr(chatID, "bad_button"))
+        return
+    }
+    text, keyboard := vacanciesListView(chatLang(chatID), page)
+    answerCallback(query.ID, "")
+    if keyboard == nil {
+        editMsg(query.Message.Chat.ID, query.Message.MessageID, text)
+        return
+    }
+    editMsgWithKeyboard(query.Message.Chat.ID, query.Message.MessageID, text, *keyboard)
+}
+
+// Кнопки карточки вакансии
+func handleVacancyCallback(query *tgbotapi.CallbackQuery, args []string) {
+    chatID := callbackChatID(query)
+    if len(args) != 2 {
+        answerCallback(query.ID, tr(chatID, "bad_button"))
+        return
+    }
+    vacID, err := strconv.Atoi(args[1])
+    if err != nil {
+        answerCallback(query.ID, tr(chatID, "bad_button"))
+        return
+    }
+    vacancyIndex := findVacancyIndex(vacID)
+    if vacancyIndex == -1 {
+        answerCallback(query.ID, tr(chatID, "vac.not_found", vacID))
+        return
+    }
+    vac := vacancies[vacancyIndex]
+
+    switch args[0] {
+    case "show":
+        answerCallback(query.ID, "")
+        showVacancy(chatID, vacID)
+    case "accept":
+        answerCallback(query.ID, "")
+        processAcceptOrder(chatID, fmt.Sprintf("!%d", vacID))
+    case "respond":
+        user := getUser(chatID)
+        if user == nil {
+            answerCallback(query.ID, tr(chatID, "register_first"))
+            return
+        }
+        if vac.Accepted {
+            answerCallback(query.ID, tr(chatID, "vac.taken"))
+            return
+        }
+        answerCallback(query.ID, tr(chatID, "vac.respond_to", vacID))
+        startConversation(chatID, FlowResponse, strconv.Itoa(vacID))
+    case "author":
+        answerCallback(query.ID, "")
+        author := getUserByUserID(vac.AuthorID)
+        if author == nil {
+            sendMsg(chatID, tr(chatID, "vac.author_gone"))
+            return
+        }
+        showPublicProfile(chatID, author)
+    case "report":
+        answerCallback(query.ID, tr(chatID, "vac.reported"))
+        reporter := "@" + query.From.UserName
+        if user := getUser(chatID); user != nil {
+            reporter = fmt.Sprintf("@%s (ID: %d)", user.Username, user.UserID)
+        }
+        for _, admin := range []string{AdminUser1, AdminUser2} {
+            if adminUser := getUserByUsername(admin); adminUser != nil {
+                sendMsg(adminUser.ChatID, tr(adminUser.ChatID, "vac.report_note", reporter, vacancyCardText(chatLang(adminUser.ChatID), vac), vac.ID))
+            }
+        }
+        logWarn("Жалоба на вакансию", "vacancy_id", vac.ID, "chat_id", chatID, "username", query.From.UserName)
+    default:
+        answerCallback(query.ID, tr(chatID, "unknown_button"))
+    }
+}
+
+// Публичный профиль пользователя
+func showPublicProfile(chatID int64, user *User) {
+    bio := user.Bio
+    if bio == "" {
+        bio = tr(chatID, "vac.not_set")
+    }
+    open, accepted := 0, 0
+    for _, vac := range vacancies {
+        if !vacancyBelongsTo(vac, user.UserID, user.ChatID) || vac.OnModeration {
+            continue
+        }
+        switch {
+        case vac.Accepted:
+            accepted++
+        case !vac.OnHold:
+            open++
+        }
+    }
+    sendMsg(chatID, tr(chatID, "profile.public",
+        user.MinecraftNick+nickBadge(user), user.UserID, user.Username, bio, open, accepted, user.UserID))
+}
+
+func callbackChatID(query *tgbotapi.CallbackQuery) int64 {
+    if query.Message != nil {
+        return query.Message.Chat.ID
+    }
+    return query.From.ID
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+    "fmt"
+    "strings"
+)
+
+// Вакансии и отклики ссылаются на пользователей по внутреннему ID (User.UserID).
+// Имена в Author, AcceptedBy и Responder — снимок ника на момент записи,
+// показываются, только если пользователя больше нет.
+
+// Имя для показа: текущий ник пользователя или сохранённое имя
+func displayName(userID int, fallback string) string {
+    if userID != 0 {
+        if user := getUserByUserID(userID); user != nil && user.MinecraftNick != "" {
+            return user.MinecraftNick
+        }
+    }
+    return fallback
+}
+
+func vacancyAuthorName(vac Vacancy) string {
+    return displayName(vac.AuthorID, vac.Author)
+}
+
+func vacancyAcceptorName(vac Vacancy) string {
+    return displayName(vac.AcceptorID, vac.AcceptedBy)
+}
+
+// Принадлежит ли вакансия пользователю. Вакансии, автора которых не удалось
+// определить при миграции (AuthorID == 0), узнаются по чату.
+func vacancyBelongsTo(vac Vacancy, userID int, chatID int64) bool {
+    if vac.AuthorID != 0 {
+        return vac.AuthorID == userID
+    }
+    return vac.ChatID == chatID
+}
+
+// Пользователь по сохранённому имени: "@имя" ищется среди username, остальное —
+// среди ников. Если имя подходит нескольким пользователям (в том числе ник
+// одного совпадает с username другого), возвращается ambiguous.
+// usernameFirst: имя без "@" сначала ищется среди username — так записаны
+// старые отклики.
+func findUserByName(name string, usernameFirst bool) (*User, bool) {
+    name = strings.TrimSpace(name)
+    byUsername := strings.HasPrefix(name, "@")
+    name = strings.TrimPrefix(name, "@")
+    if name == "" {
+        return nil, false
+    }
+    userMutex.Lock()
+    defer userMutex.Unlock()
+    if usernameFirst && !byUsername {
+        var match *User
+        matches := 0
+        for i := range users {
+            if strings.EqualFold(users[i].Username, name) {
+                match = &users[i]
+                matches++
+            }
+        }
+        if matches == 1 {
+            return match, false
+        }
+    }
+    var found *User
+    ambiguous := false
+    for i := range users {
+        user := &users[i]
+        byNick := strings.EqualFold(user.MinecraftNick, name)
+        byName := strings.EqualFold(user.Username, name)
+        if (byUsername && byName) || (!byUsername && byNick) {
+            if found != nil {
+                ambiguous = true
+            }
+            found = user
+        } else if byNick || byName {
+            ambiguous = true
+        }
+    }
+    if ambiguous {
+        return nil, true
+    }
+    return found, false
+}
+
+// Поиск при миграции: неоднозначные и ненайденные имена остаются без ссылки и пишутся в лог
+func linkUserByName(name string, usernameFirst bool, what string) *User {
+    user, ambiguous := findUserByName(name, usernameFirst)
+    switch {
+    case ambiguous:
+        logWarn("Имя подходит нескольким пользователям, ссылка не заполнена", "record", what, "name", name)
+    case user == nil && name != "":
+        logWarn("Пользователь не найден, ссылка не заполнена", "record", what, "name", name)
+    }
+    return user
+}
+
+// Заполнение ID в данных, записанных до появления ссылок по ID.
+// Вызывается после загрузки пользователей, вакансий и откликов.
+func migrateUserReferences() {
+    vacanciesFixed := 0
+    for i := range vacancies {
+        vac := &vacancies[i]
+        changed := false
+        if vac.AuthorID == 0 {
+            author := getUser(vac.ChatID)
+            if author == nil {
+                author = linkUserByName(vac.Author, false, fmt.Sprintf("Автор вакансии #%d", vac.ID))
+            }
+            if author != nil {
+                vac.AuthorID = author.UserID
+                changed = true
+            }
+        }
+        if vac.Accepted && vac.AcceptorID == 0 {
+            var acceptor *User
+            if vac.AcceptedByID != 0 {
+                acceptor = getUser(vac.AcceptedByID)
+            }
+            if acceptor == nil {
+                acceptor = linkUserByName(vac.AcceptedBy, false, fmt.Sprintf("Исполнитель вакансии #%d", vac.ID))
+            }
+            if acceptor != nil {
+                vac.AcceptorID = acceptor.UserID
+                changed = true
+            }
+        }
+        if changed {
+            vacanciesFixed++
+        }
+    }
+    if vacanciesFixed > 0 {
+        saveVacancies()
+    }
+
+    responsesFixed := 0
+    for i := range responses {
+        if responses[i].ResponderID != 0 {
+            continue
+        }
+        // Старые отклики хранят username без "@", старые предложения — ник
+        what := fmt.Sprintf("Отклик на вакансию #%d", responses[i].VacancyID)
+        if responder := linkUserByName(responses[i].Responder, true, what); responder != nil {
+            responses[i].ResponderID = responder.UserID
+            responsesFixed++
+        }
+    }
+    if responsesFixed > 0 {
+        saveResponses()
+    }
+    if vacanciesFixed > 0 || responsesFixed > 0 {
+        logInfo("Ссылки на пользователей заполнены", "vacancies", vacanciesFixed, "responses", responsesFixed)
+    }
+}
+
+func isUserIDReferenced(userID int) bool {
+    for _, vac := range vacancies {
+        if vac.AuthorID == userID || vac.AcceptorID == userID {
+            return true
+        }
+    }
+    for _, resp := range responses {
+        if resp.ResponderID == userID {
+            return true
+        }
+    }
+    // Мьютексы берутся по очереди, не вложенно
+    found := false
+    strikeMutex.Lock()
+    for _, strike := range strikes {
+        found = found || strike.UserID == userID
+    }
+    strikeMutex.Unlock()
+    ticketMutex.Lock()
+    for _, t := range tickets {
+        found = found || t.UserID == userID
+    }
+    ticketMutex.Unlock()
+    historyMutex.Lock()
+    for _, b := range banHistory {
+        found = found || b.UserID == userID
+    }
+    for _, a := range appeals {
+        found = found || a.UserID == userID
+    }
+    historyMutex.Unlock()
+    calloutMutex.Lock()
+    for _, c := range callouts {
+        found = found || c.UserID == userID
+    }
+    calloutMutex.Unlock()
+    return found
+}
+
+// Перенос ссылок при смене ID пользователя: вакансии, отклики, страйки,
+// обращения, история банов, апелляции и отзывы
+func remapUserID(oldID int, newID int) {
+    for i := range vacancies {
+        if vacancies[i].AuthorID == oldID {
+            vacancies[i].AuthorID = newID
+        }
+        if vacancies[i].AcceptorID == oldID {
+            vacancies[i].AcceptorID = newID
+        }
+    }
+    saveVacancies()
+    for i := range responses {
+        if responses[i].ResponderID == oldID {
+            responses[i].ResponderID = newID
+        }
+    }
+    saveResponses()
+
+    strikeMutex.Lock()
+    moved := false
+    for i := range strikes {
+        if strikes[i].UserID == oldID {
+            strikes[i].UserID = newID
+            moved = true
+        }
+    }
+    if moved {
+        saveStrikes()
+    }
+    strikeMutex.Unlock()
+
+    ticketMutex.Lock()
+    moved = false
+    for i := range tickets {
+        if tickets[i].UserID == oldID {
+            tickets[i].UserID = newID
+            moved = true
+        }
+    }
+    if moved {
+        saveTickets()
+    }
+    ticketMutex.Unlock()
+
+    historyMutex.Lock()
+    moved = false
+    for i := range banHistory {
+        if banHistory[i].UserID == oldID {
+            banHistory[i].UserID = newID
+            moved = true
+        }
+    }
+    if moved {
+        saveBanHistory()
+    }
+    moved = false
+    for i := range appeals {
+        if appeals[i].UserID == oldID {
+            appeals[i].UserID = newID
+            moved = true
+        }
+    }
+    if moved {
+        saveAppeals()
+    }
+    historyMutex.Unlock()
+
+    calloutMutex.Lock()
+    moved = false
+    for i := range callouts {
+        if callouts[i].UserID == oldID {
+            callouts[i].UserID = newID
+            moved = true
+        }
+    }
+    if moved {
+        saveCallouts()
+    }
+    calloutMutex.Unlock()
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+    "fmt"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Подтверждение ника: бот шепчет код игроку на сервере, игрок присылает его боту
+const (
+    VerifyCodeLifetime = 10 * time.Minute
+    VerifyCodeCooldown = time.Minute
+    VerifyMaxAttempts  = 5
+    VerifiedBadge      = " ✔️"
+)
+
+type nickVerification struct {
+    Nick     string
+    Code     string
+    IssuedAt time.Time
+    Attempts int
+}
+
+var (
+    nickVerifications = make(map[int64]*nickVerification)
+    verifyMutex       sync.Mutex
+)
+
+// Отметка о подтверждённом нике рядом с ником
+func nickBadge(user *User) string {
+    if user.Verified {
+        return VerifiedBadge
+    }
+    return ""
+}
+
+// /verify — запросить код, /verify КОД — подтвердить ник
+func processVerifyCommand(chatID int64, text string) {
+    user := getUser(chatID)
+    if user == nil || user.MinecraftNick == "" {
+        sendMsg(chatID, tr(chatID, "register_first"))
+        return
+    }
+    if user.Verified {
+        sendMsg(chatID, tr(chatID, "verify.already", user.MinecraftNick))
+        return
+    }
+    if RconAddress == "" {
+        sendMsg(chatID, tr(chatID, "verify.disabled"))
+        return
+    }
+    parts := strings.Fields(text)
+    if len(parts) == 1 {
+        requestVerificationCode(chatID, user)
+        return
+    }
+    confirmVerificationCode(chatID, user, parts[1])
+}
+
+func requestVerificationCode(chatID int64, user *User) {
+    nick := user.MinecraftNick
+    if !minecraftNickPattern.MatchString(nick) {
+        sendMsg(chatID, tr(chatID, "verify.bad_nick", nick))
+        return
+    }
+    code := fmt.Sprintf("%06d", rng.Intn(1000000))
+    verifyMutex.Lock()
+    pending, ok := nickVerifications[chatID]
+    if ok && time.Since(pending.IssuedAt) < VerifyCodeCooldown {
+        verifyMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "verify.cooldown"))
+        return
+    }
+    // Код без значения — запрос к серверу ещё идёт
+    nickVerifications[chatID] = &nickVerification{Nick: nick, IssuedAt: time.Now()}
+    verifyMutex.Unlock()
+
+    // Запросы к RCON блокируются, поэтому выполняются в отдельной горутине,
+    // а результат возвращается в цикл обновлений
+    whisper := tr(chatID, "verify.whisper", code)
+    go func() {
+        result := whisperVerificationCode(nick, whisper)
+        runOnMain(func() { finishVerificationRequest(chatID, user, nick, code, result) })
+    }()
+}
+
+// Отправка кода игроку: ключ сообщения для пользователя, пусто — код отправлен
+func whisperVerificationCode(nick string, whisper string) string {
+    players, err := rconOnlinePlayers()
+    if err != nil {
+        logError("RCON: не удалось получить список игроков", "error", err)
+        return "verify.server_error"
+    }
+    online := ""
+    for _, player := range players {
+        if strings.EqualFold(player, nick) {
+            online = player
+            break
+        }
+    }
+    if online == "" {
+        return "verify.offline"
+    }
+    if _, err := rconCommand(fmt.Sprintf("tell %s %s", online, whisper)); err != nil {
+        logError("RCON: не удалось отправить код", "nick", online, "error", err)
+        return "verify.server_error"
+    }
+    return ""
+}
+
+func finishVerificationRequest(chatID int64, user *User, nick string, code string, result string) {
+    verifyMutex.Lock()
+    pending, ok := nickVerifications[chatID]
+    current := ok && pending.Code == "" && pending.Nick == nick
+    if current {
+        if result == "" {
+            pending.Code = code
+            pending.IssuedAt = time.Now()
+        } else {
+            delete(nickVerifications, chatID)
+        }
+    }
+    verifyMutex.Unlock()
+    if !current {
+        // Ник сменили, пока шёл запрос
+        return
+    }
+    switch result {
+    case "":
+        sendMsg(chatID, tr(chatID, "verify.sent", formatDurationFor(chatID, VerifyCodeLifetime)))
+        logInfo("Запрошен код подтверждения ника", "chat_id", chatID, "user_id", user.UserID, "username", user.Username, "command", "/verify", "nick", nick)
+    case "verify.offline":
+        sendMsg(chatID, tr(chatID, result, nick))
+    default:
+        sendMsg(chatID, tr(chatID, result))
+    }
+}
+
+func confirmVerificationCode(chatID int64, user *User, code string) {
+    verifyMutex.Lock()
+    pending, ok := nickVerifications[chatID]
+    if !ok || pending.Code == "" {
+        verifyMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "verify.no_code"))
+        return
+    }
+    if time.Since(pending.IssuedAt) > VerifyCodeLifetime || pending.Nick != user.MinecraftNick {
+        delete(nickVerifications, chatID)
+        verifyMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "verify.expired"))
+        return
+    }
+    if pending.Code != code {
+        pending.Attempts++
+        left := VerifyMaxAttempts - pending.Attempts
+        if left <= 0 {
+            delete(nickVerifications, chatID)
+            verifyMutex.Unlock()
+            sendMsg(chatID, tr(chatID, "verify.expired"))
+            return
+        }
+        verifyMutex.Unlock()
+        sendMsg(chatID, tr(chatID, "verify.wrong", left))
+        return
+    }
+    delete(nickVerifications, chatID)
+    verifyMutex.Unlock()
+
+    userMutex.Lock()
+    user.Verified = true
+    userMutex.Unlock()
+    saveUsers()
+    sendMsg(chatID, tr(chatID, "verify.done", user.MinecraftNick))
+    logInfo("Ник подтверждён", "chat_id", chatID, "user_id", user.UserID, "username", user.Username, "nick", user.MinecraftNick)
+}
+
+// Сброс подтверждения при смене ника (вызывать под userMutex)
+func resetVerification(user *User) {
+    user.Verified = false
+    verifyMutex.Lock()
+    delete(nickVerifications, user.ChatID)
+    verifyMutex.Unlock()
+}
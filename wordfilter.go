@@ -0,0 +1,289 @@
+package main
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+    "unicode"
+)
+
+// Виды правил фильтра запрещённых слов.
+// Синтаксис записей в forbidden_words.txt и /banwords:
+//   слово       — подстрока, в том числе внутри других слов (по умолчанию, как раньше)
+//   *слово*     — то же, явно
+//   =слово      — только целое слово
+//   re:шаблон   — регулярное выражение
+//   +слово      — исключение (разрешённое слово)
+//   ?запись     — подозрительное: не отклоняет, а отправляет на модерацию
+const (
+    RuleWord      = "word"
+    RuleSubstring = "substring"
+    RuleRegex     = "regex"
+    RuleAllow     = "allow"
+)
+
+type forbiddenRule struct {
+    Raw        string
+    Kind       string
+    Pattern    string
+    Regex      *regexp.Regexp
+    Suspicious bool
+}
+
+var forbiddenRules []forbiddenRule
+
+// Ошибка в записи фильтра. Текст берётся из каталога: в лог на языке по
+// умолчанию, админу — на его языке.
+type ruleError struct {
+    key  string
+    args []interface{}
+}
+
+func (e *ruleError) Error() string {
+    return T(DefaultLang, e.key, e.args...)
+}
+
+// Похожие символы других алфавитов и leetspeak → кириллица. Заменяются только
+// в словах, где уже есть кириллица ("дуpак", "д0лбо"), чтобы латиница и числа
+// не превращались в русские слова.
+var homoglyphs = map[rune]rune{
+    'a': 'а', 'b': 'в', 'c': 'с', 'e': 'е', 'h': 'н', 'k': 'к', 'm': 'м',
+    'o': 'о', 'p': 'р', 't': 'т', 'u': 'и', 'x': 'х', 'y': 'у',
+    'α': 'а', 'β': 'в', 'ε': 'е', 'κ': 'к', 'ο': 'о', 'ρ': 'р', 'τ': 'т', 'χ': 'х',
+    '@': 'а', '0': 'о', '3': 'з', '4': 'ч', '6': 'б', '$': 'с', '€': 'е',
+}
+
+// Разбор записи фильтра
+func parseForbiddenRule(entry string) (forbiddenRule, error) {
+    entry = strings.TrimSpace(entry)
+    if strings.HasPrefix(entry, "?") {
+        rule, err := parseForbiddenRule(strings.TrimPrefix(entry, "?"))
+        if err == nil && rule.Kind == RuleAllow {
+            err = &ruleError{key: "banword.allow_suspicious"}
+        }
+        rule.Raw = entry
+        rule.Suspicious = true
+        return rule, err
+    }
+    rule := forbiddenRule{Raw: entry}
+    switch {
+    case strings.HasPrefix(entry, "re:"):
+        expr := strings.TrimSpace(strings.TrimPrefix(entry, "re:"))
+        if expr == "" {
+            return rule, &ruleError{key: "banword.empty_regex"}
+        }
+        re, err := regexp.Compile("(?i)" + expr)
+        if err != nil {
+            return rule, &ruleError{key: "banword.bad_regex", args: []interface{}{err}}
+        }
+        rule.Kind = RuleRegex
+        rule.Pattern = expr
+        rule.Regex = re
+        return rule, nil
+    case strings.HasPrefix(entry, "+"):
+        rule.Kind = RuleAllow
+        entry = strings.TrimPrefix(entry, "+")
+    case strings.HasPrefix(entry, "="):
+        rule.Kind = RuleWord
+        entry = strings.TrimPrefix(entry, "=")
+    case len(entry) > 2 && strings.HasPrefix(entry, "*") && strings.HasSuffix(entry, "*"):
+        rule.Kind = RuleSubstring
+        entry = strings.TrimSuffix(strings.TrimPrefix(entry, "*"), "*")
+    default:
+        rule.Kind = RuleSubstring
+    }
+    rule.Pattern = strings.Join(normalizeTokens(entry), " ")
+    if rule.Pattern == "" {
+        return rule, &ruleError{key: "banword.no_letters", args: []interface{}{rule.Raw}}
+    }
+    return rule, nil
+}
+
+// Пересборка правил из forbiddenWords (вызывать под forbiddenWordsMu)
+func rebuildForbiddenRules() {
+    forbiddenRules = nil
+    for _, entry := range forbiddenWords {
+        rule, err := parseForbiddenRule(entry)
+        if err != nil {
+            logWarn("Пропущена запись фильтра", "entry", entry, "error", err)
+            continue
+        }
+        forbiddenRules = append(forbiddenRules, rule)
+    }
+}
+
+// Упрощённая нормализация (не полный NFKC): нижний регистр, ё → е, полноширинные
+// символы → ASCII, без невидимых символов и отдельных диакритических знаков
+func foldText(text string) string {
+    var sb strings.Builder
+    for _, r := range text {
+        if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Cf, r) {
+            continue
+        }
+        if r >= 0xFF01 && r <= 0xFF5E {
+            r -= 0xFEE0
+        }
+        r = unicode.ToLower(r)
+        if r == 'ё' {
+            r = 'е'
+        }
+        sb.WriteRune(r)
+    }
+    return sb.String()
+}
+
+// Замена похожих символов в слове, где есть кириллица. Остальные слова
+// остаются как есть, символы вроде @ и $ в них считаются разделителями.
+func foldHomoglyphs(token string) []string {
+    hasCyrillic := false
+    for _, r := range token {
+        if unicode.Is(unicode.Cyrillic, r) {
+            hasCyrillic = true
+            break
+        }
+    }
+    if !hasCyrillic {
+        return strings.FieldsFunc(token, func(r rune) bool {
+            return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+        })
+    }
+    var sb strings.Builder
+    for _, r := range token {
+        if folded, ok := homoglyphs[r]; ok {
+            r = folded
+        }
+        sb.WriteRune(r)
+    }
+    return []string{sb.String()}
+}
+
+// Схлопывание повторяющихся букв: "дууурак" → "дурак"
+func squeezeRepeats(word string) string {
+    var sb strings.Builder
+    var prev rune
+    for i, r := range word {
+        if i > 0 && r == prev {
+            continue
+        }
+        sb.WriteRune(r)
+        prev = r
+    }
+    return sb.String()
+}
+
+// Нормализованные слова текста. Цепочки одиночных букв ("д у р а к", "д.у.р.а.к")
+// склеиваются в одно слово.
+func normalizeTokens(text string) []string {
+    var raw []string
+    for _, word := range strings.FieldsFunc(foldText(text), func(r rune) bool {
+        _, glyph := homoglyphs[r]
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !glyph
+    }) {
+        raw = append(raw, foldHomoglyphs(word)...)
+    }
+    var tokens []string
+    var run []string
+    flush := func() {
+        if len(run) >= 3 {
+            tokens = append(tokens, squeezeRepeats(strings.Join(run, "")))
+        } else {
+            for _, t := range run {
+                tokens = append(tokens, squeezeRepeats(t))
+            }
+        }
+        run = nil
+    }
+    for _, t := range raw {
+        if len([]rune(t)) == 1 {
+            run = append(run, t)
+            continue
+        }
+        flush()
+        tokens = append(tokens, squeezeRepeats(t))
+    }
+    flush()
+    return tokens
+}
+
+// Проверка текста по правилам (вызывать под forbiddenWordsMu).
+// suspicious выбирает набор правил: запрещающие или модерационные.
+func matchForbiddenRules(text string, suspicious bool) (bool, string) {
+    tokens := normalizeTokens(text)
+    allowed := make(map[string]bool)
+    for _, rule := range forbiddenRules {
+        if rule.Kind == RuleAllow {
+            allowed[rule.Pattern] = true
+        }
+    }
+
+    var kept []string
+    for _, t := range tokens {
+        if !allowed[t] {
+            kept = append(kept, t)
+        }
+    }
+    // Подстрока ищется внутри слов, но не на стыке соседних ("дома тоже" не
+    // содержит "мат"). Разнесённые буквы уже склеены в normalizeTokens.
+    words := " " + strings.Join(kept, " ") + " "
+    lowered := strings.ToLower(text)
+
+    for _, rule := range forbiddenRules {
+        if rule.Suspicious != suspicious {
+            continue
+        }
+        switch rule.Kind {
+        case RuleWord:
+            if strings.Contains(words, " "+rule.Pattern+" ") {
+                return true, rule.Pattern
+            }
+        case RuleSubstring:
+            if strings.Contains(words, rule.Pattern) {
+                return true, rule.Pattern
+            }
+        case RuleRegex:
+            if rule.Regex.MatchString(lowered) || rule.Regex.MatchString(strings.Join(tokens, " ")) {
+                return true, rule.Raw
+            }
+        }
+    }
+    return false, ""
+}
+
+func forbiddenRuleKindText(lang string, kind string) string {
+    switch kind {
+    case RuleWord, RuleSubstring, RuleRegex, RuleAllow, "suspicious":
+        return T(lang, "banword.kind_"+kind)
+    }
+    return kind
+}
+
+// Список правил фильтра для админов
+func listForbiddenRules(chatID int64) {
+    forbiddenWordsMu.RLock()
+    byKind := make(map[string][]string)
+    for _, rule := range forbiddenRules {
+        kind := rule.Kind
+        if rule.Suspicious {
+            kind = "suspicious"
+        }
+        byKind[kind] = append(byKind[kind], rule.Raw)
+    }
+    total := len(forbiddenRules)
+    forbiddenWordsMu.RUnlock()
+
+    if total == 0 {
+        sendMsg(chatID, tr(chatID, "banword.list_empty"))
+        return
+    }
+    lang := chatLang(chatID)
+    var sb strings.Builder
+    sb.WriteString(T(lang, "banword.list_header", total))
+    for _, kind := range []string{RuleWord, RuleSubstring, RuleRegex, "suspicious", RuleAllow} {
+        if len(byKind[kind]) == 0 {
+            continue
+        }
+        sb.WriteString(fmt.Sprintf("\n%s:\n%s\n", forbiddenRuleKindText(lang, kind), strings.Join(byKind[kind], ", ")))
+    }
+    sb.WriteString(T(lang, "banword.list_hint"))
+    sendMsg(chatID, sb.String())
+}
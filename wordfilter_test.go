@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func setForbiddenWords(t *testing.T, words ...string) {
+    forbiddenWordsMu.Lock()
+    saved := forbiddenWords
+    forbiddenWords = words
+    rebuildForbiddenRules()
+    forbiddenWordsMu.Unlock()
+    t.Cleanup(func() {
+        forbiddenWordsMu.Lock()
+        forbiddenWords = saved
+        rebuildForbiddenRules()
+        forbiddenWordsMu.Unlock()
+    })
+}
+
+func TestSubstringRulesStayInsideWords(t *testing.T) {
+    setForbiddenWords(t, "мат", "идиот", "=дурак")
+    tests := map[string]bool{
+        "я дома тоже":         false,
+        "иди отсюда":          false,
+        "без мата":            true,
+        "ты идиот":            true,
+        "м а т":               true,
+        "д.у.р.а.к":           true,
+        "дуpак":               true,
+        "дураки не при чём":   false,
+        "материал на продажу": true,
+    }
+    forbiddenWordsMu.RLock()
+    defer forbiddenWordsMu.RUnlock()
+    for text, want := range tests {
+        if got, _ := matchForbiddenRules(text, false); got != want {
+            t.Errorf("matchForbiddenRules(%q) = %v, ожидалось %v", text, got, want)
+        }
+    }
+}